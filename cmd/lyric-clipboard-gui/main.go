@@ -3,27 +3,37 @@ package main
 import (
 	"flag"
 	"log"
+	"time"
 
 	"github.com/arnavpraneet/lyric-clipboard-app/internal/config"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/control"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/debuglog"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/discord"
 	"github.com/arnavpraneet/lyric-clipboard-app/internal/gui"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/hotkey"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/httpapi"
 	"github.com/arnavpraneet/lyric-clipboard-app/internal/orchestrator"
 )
 
+// hotkeyOffsetStep is how far each offset hotkey press moves the lyrics
+const hotkeyOffsetStep = 100 * time.Millisecond
+
 func main() {
 	// Command-line flags
 	configPath := flag.String("config", "", "Path to configuration file (default: ~/.config/lyric-clipboard/config.json)")
 	demoMode := flag.Bool("demo", false, "Run in demo mode with a sample song")
 	demoArtist := flag.String("artist", "", "Artist name for demo mode")
 	demoTitle := flag.String("title", "", "Song title for demo mode")
+	demoLRC := flag.String("demo-lrc", "", "Run in demo mode, playing this LRC or SRT file offline")
 	generateConfig := flag.Bool("generate-config", false, "Generate example configuration file and exit")
 	flag.Parse()
 
 	// Generate config if requested
 	if *generateConfig {
-		if err := config.GenerateExample(); err != nil {
+		if err := config.GenerateExample(*configPath); err != nil {
 			log.Fatalf("Failed to generate config: %v", err)
 		}
-		path, _ := config.DefaultConfigPath()
+		path, _ := config.ResolvePath(*configPath)
 		log.Printf("Configuration file generated at: %s", path)
 		return
 	}
@@ -33,6 +43,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	debuglog.SetEnabled(cfg.Debug)
 
 	// Override config with command-line flags
 	if *demoMode {
@@ -44,15 +55,71 @@ func main() {
 	if *demoTitle != "" {
 		cfg.DemoTitle = *demoTitle
 	}
+	if *demoLRC != "" {
+		cfg.DemoMode = true
+		cfg.DemoLRC = *demoLRC
+	}
 
 	// Create orchestrator with configuration
 	orchConfig := orchestrator.Config{
-		PollInterval:    cfg.PollInterval,
-		LyricOffset:     cfg.LyricOffset,
-		UpdateClipboard: cfg.UpdateClipboard,
-		DemoMode:        cfg.DemoMode,
-		DemoArtist:      cfg.DemoArtist,
-		DemoTitle:       cfg.DemoTitle,
+		PollInterval:           cfg.PollInterval,
+		LyricOffset:            cfg.LyricOffset,
+		UpdateClipboard:        cfg.UpdateClipboard,
+		EnableCache:            cfg.EnableCache,
+		DemoMode:               cfg.DemoMode,
+		DemoArtist:             cfg.DemoArtist,
+		DemoTitle:              cfg.DemoTitle,
+		ShowNotifications:      cfg.ShowNotifications,
+		AdaptivePolling:        cfg.AdaptivePolling,
+		Romanize:               cfg.Romanize,
+		ShowTranslation:        cfg.ShowTranslation,
+		TranslationDir:         cfg.TranslationDir,
+		ClipboardTemplate:      cfg.ClipboardTemplate,
+		PreferredPlayers:       cfg.PreferredPlayers,
+		InstrumentalText:       cfg.InstrumentalText,
+		ClipboardDebounce:      cfg.ClipboardDebounce,
+		UserAgent:              cfg.UserAgent,
+		CacheMaxEntries:        cfg.CacheMaxEntries,
+		ClipboardMode:          cfg.ClipboardMode,
+		ClipboardMaxLength:     cfg.ClipboardMaxLength,
+		ClearOnTrackEnd:        cfg.ClearOnTrackEnd,
+		TrackEndWindow:         cfg.TrackEndWindow,
+		ClipboardBackend:       cfg.ClipboardBackend,
+		ClipboardTimeout:       cfg.ClipboardTimeout,
+		IncludeTimestamp:       cfg.IncludeTimestamp,
+		MaxDetectorFailures:    cfg.MaxDetectorFailures,
+		LeadTime:               cfg.LeadTime,
+		MinLineDisplay:         cfg.MinLineDisplay,
+		NegativeCacheTTL:       cfg.NegativeCacheTTL,
+		FetchTimeout:           cfg.FetchTimeout,
+		DemoLRC:                cfg.DemoLRC,
+		RadioMode:              cfg.RadioMode,
+		SpotifyToken:           cfg.SpotifyToken,
+		SpotifyClientID:        cfg.SpotifyClientID,
+		SpotifyClientSecret:    cfg.SpotifyClientSecret,
+		SpotifyRefreshToken:    cfg.SpotifyRefreshToken,
+		OnLineCommand:          cfg.OnLineCommand,
+		PlayerSelection:        cfg.PlayerSelection,
+		QuietHoursStart:        cfg.QuietHoursStart,
+		QuietHoursEnd:          cfg.QuietHoursEnd,
+		StripAnnotations:       cfg.StripAnnotations,
+		AnnotationPatterns:     cfg.AnnotationPatterns,
+		LyricsRateLimit:        cfg.LyricsRateLimit,
+		IgnoredPlayers:         cfg.IgnoredPlayers,
+		ClipboardMinInterval:   cfg.ClipboardMinInterval,
+		StartupDelay:           cfg.StartupDelay,
+		MaxLineLength:          cfg.MaxLineLength,
+		ClearClipboardOnNoSong: cfg.ClearClipboardOnNoSong,
+		NoSongText:             cfg.NoSongText,
+		DetectDrift:            cfg.DetectDrift,
+		AutoOffset:             cfg.AutoOffset,
+		ClipboardHistoryLines:  cfg.ClipboardHistoryLines,
+		PlayerctlFallback:      cfg.PlayerctlFallback,
+		SkipLongTracksMinutes:  cfg.SkipLongTracksMinutes,
+		FIFOPath:               cfg.FIFOPath,
+		ForceRewriteDuplicates: cfg.ForceRewriteDuplicates,
+		LocalDBPath:            cfg.LocalDBPath,
+		CopyOnDemand:           cfg.CopyOnDemand,
 	}
 
 	orch, err := orchestrator.NewOrchestrator(orchConfig)
@@ -60,14 +127,67 @@ func main() {
 		log.Fatalf("Failed to create orchestrator: %v", err)
 	}
 
-	if cfg.DemoMode {
+	if cfg.DemoMode && cfg.DemoLRC != "" {
+		log.Printf("Running in DEMO mode with lyrics from %s", cfg.DemoLRC)
+	} else if cfg.DemoMode {
 		log.Printf("Running in DEMO mode with: %s - %s", cfg.DemoArtist, cfg.DemoTitle)
 	}
 	if cfg.LyricOffset != 0 {
 		log.Printf("Lyric offset: %v", cfg.LyricOffset)
 	}
 
+	// Start the control API if configured
+	if cfg.ControlSocket != "" {
+		server := control.NewServer(orch, cfg.ControlSocket)
+		if err := server.Start(); err != nil {
+			log.Printf("Failed to start control API: %v", err)
+		} else {
+			defer server.Close()
+		}
+	}
+
+	// Start the HTTP API if configured
+	if cfg.HTTPAddr != "" {
+		server := httpapi.NewServer(orch, cfg.HTTPAddr)
+		if err := server.Start(); err != nil {
+			log.Printf("Failed to start HTTP API: %v", err)
+		} else {
+			defer server.Close()
+		}
+	}
+
+	// Register global hotkeys if enabled
+	if cfg.EnableHotkeys {
+		hotkeys, err := hotkey.Listen([]hotkey.Binding{
+			{Keys: cfg.HotkeyOffsetBack, Action: func() { orch.AdjustLyricOffset(-hotkeyOffsetStep) }},
+			{Keys: cfg.HotkeyOffsetForward, Action: func() { orch.AdjustLyricOffset(hotkeyOffsetStep) }},
+			{Keys: cfg.HotkeyPause, Action: func() { orch.TogglePause() }},
+			{Keys: cfg.HotkeyCopyLine, Action: func() {
+				if err := orch.CopyCurrentLine(); err != nil {
+					log.Printf("Failed to copy line: %v", err)
+				}
+			}},
+		})
+		if err != nil {
+			log.Printf("Global hotkeys unavailable: %v", err)
+		} else {
+			defer hotkeys.Close()
+		}
+	}
+
+	// Show the current song on Discord if enabled
+	if cfg.DiscordRPC {
+		if cfg.DiscordClientID == "" {
+			log.Println("Discord Rich Presence is enabled but discord_client_id is not set")
+		} else {
+			presence := discord.NewPresence(orch, cfg.DiscordClientID)
+			presence.Start()
+			defer presence.Close()
+		}
+	}
+
 	// Create and run system tray GUI
-	tray := gui.NewSystemTray(orch)
+	tray := gui.NewSystemTray(orch, cfg, *configPath)
+	tray.SetIconTheme(cfg.IconTheme)
 	tray.Run()
 }
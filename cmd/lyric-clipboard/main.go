@@ -1,31 +1,66 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/clipboard"
 	"github.com/arnavpraneet/lyric-clipboard-app/internal/config"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/control"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/debuglog"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/detector"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/discord"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/hotkey"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/httpapi"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/lyrics"
 	"github.com/arnavpraneet/lyric-clipboard-app/internal/orchestrator"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/tui"
 )
 
+// hotkeyOffsetStep is how far each offset hotkey press moves the lyrics
+const hotkeyOffsetStep = 100 * time.Millisecond
+
 func main() {
 	// Command-line flags
 	configPath := flag.String("config", "", "Path to configuration file (default: ~/.config/lyric-clipboard/config.json)")
 	demoMode := flag.Bool("demo", false, "Run in demo mode with a sample song")
 	demoArtist := flag.String("artist", "", "Artist name for demo mode")
 	demoTitle := flag.String("title", "", "Song title for demo mode")
+	demoLRC := flag.String("demo-lrc", "", "Run in demo mode, playing this LRC or SRT file offline")
 	generateConfig := flag.Bool("generate-config", false, "Generate example configuration file and exit")
+	validateConfig := flag.Bool("validate", false, "Validate the configuration file and exit")
+	listPlayers := flag.Bool("list-players", false, "List detected media players and exit")
+	clipboardOn := flag.Bool("clipboard", false, "Write lyrics to the clipboard, overriding the config file")
+	clipboardOff := flag.Bool("no-clipboard", false, "Don't write lyrics to the clipboard, overriding the config file")
+	dumpLyrics := flag.Bool("dump-lyrics", false, "Fetch the lyrics for -artist and -title, print their timeline and exit")
+	dumpRaw := flag.Bool("raw", false, "With -dump-lyrics, print the lyrics exactly as lrclib.net returns them")
+	tuiMode := flag.Bool("tui", false, "Show the song and current lyric line on a single terminal line, updated in place")
+	calibrate := flag.Bool("calibrate", false, "Measure the player's position lag, print a recommended lyric_offset_ms and exit")
+	doctor := flag.Bool("doctor", false, "Check the clipboard, player detection and lrclib.net, print the results and exit")
 	flag.Parse()
 
+	// List players if requested
+	if *listPlayers {
+		os.Exit(runListPlayers())
+	}
+
+	// Validate config if requested
+	if *validateConfig {
+		os.Exit(runValidate(*configPath))
+	}
+
 	// Generate config if requested
 	if *generateConfig {
-		if err := config.GenerateExample(); err != nil {
+		if err := config.GenerateExample(*configPath); err != nil {
 			log.Fatalf("Failed to generate config: %v", err)
 		}
-		path, _ := config.DefaultConfigPath()
+		path, _ := config.ResolvePath(*configPath)
 		log.Printf("Configuration file generated at: %s", path)
 		return
 	}
@@ -35,6 +70,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	debuglog.SetEnabled(cfg.Debug)
 
 	// Override config with command-line flags
 	if *demoMode {
@@ -46,15 +82,97 @@ func main() {
 	if *demoTitle != "" {
 		cfg.DemoTitle = *demoTitle
 	}
+	if *demoLRC != "" {
+		cfg.DemoMode = true
+		cfg.DemoLRC = *demoLRC
+	}
+
+	// Only override the clipboard setting for flags that were given
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "clipboard":
+			cfg.UpdateClipboard = *clipboardOn
+		case "no-clipboard":
+			cfg.UpdateClipboard = !*clipboardOff
+		}
+	})
+
+	// Dump lyrics if requested, with the fetch settings from the config
+	if *dumpLyrics {
+		os.Exit(runDumpLyrics(cfg, *demoArtist, *demoTitle, *dumpRaw))
+	}
+
+	// Run the self-test if requested, with the settings from the config
+	if *doctor {
+		os.Exit(runDoctor(cfg))
+	}
+
+	// Calibrate if requested, after the flags have picked the detector
+	if *calibrate {
+		os.Exit(runCalibrate(cfg))
+	}
 
 	// Create orchestrator with configuration
 	orchConfig := orchestrator.Config{
-		PollInterval:    cfg.PollInterval,
-		LyricOffset:     cfg.LyricOffset,
-		UpdateClipboard: cfg.UpdateClipboard,
-		DemoMode:        cfg.DemoMode,
-		DemoArtist:      cfg.DemoArtist,
-		DemoTitle:       cfg.DemoTitle,
+		PollInterval:           cfg.PollInterval,
+		LyricOffset:            cfg.LyricOffset,
+		UpdateClipboard:        cfg.UpdateClipboard,
+		EnableCache:            cfg.EnableCache,
+		DemoMode:               cfg.DemoMode,
+		DemoArtist:             cfg.DemoArtist,
+		DemoTitle:              cfg.DemoTitle,
+		ShowNotifications:      cfg.ShowNotifications,
+		AdaptivePolling:        cfg.AdaptivePolling,
+		Romanize:               cfg.Romanize,
+		ShowTranslation:        cfg.ShowTranslation,
+		TranslationDir:         cfg.TranslationDir,
+		ClipboardTemplate:      cfg.ClipboardTemplate,
+		PreferredPlayers:       cfg.PreferredPlayers,
+		InstrumentalText:       cfg.InstrumentalText,
+		ClipboardDebounce:      cfg.ClipboardDebounce,
+		UserAgent:              cfg.UserAgent,
+		CacheMaxEntries:        cfg.CacheMaxEntries,
+		ClipboardMode:          cfg.ClipboardMode,
+		ClipboardMaxLength:     cfg.ClipboardMaxLength,
+		ClearOnTrackEnd:        cfg.ClearOnTrackEnd,
+		TrackEndWindow:         cfg.TrackEndWindow,
+		ClipboardBackend:       cfg.ClipboardBackend,
+		ClipboardTimeout:       cfg.ClipboardTimeout,
+		IncludeTimestamp:       cfg.IncludeTimestamp,
+		MaxDetectorFailures:    cfg.MaxDetectorFailures,
+		LeadTime:               cfg.LeadTime,
+		MinLineDisplay:         cfg.MinLineDisplay,
+		NegativeCacheTTL:       cfg.NegativeCacheTTL,
+		FetchTimeout:           cfg.FetchTimeout,
+		DemoLRC:                cfg.DemoLRC,
+		RadioMode:              cfg.RadioMode,
+		SpotifyToken:           cfg.SpotifyToken,
+		SpotifyClientID:        cfg.SpotifyClientID,
+		SpotifyClientSecret:    cfg.SpotifyClientSecret,
+		SpotifyRefreshToken:    cfg.SpotifyRefreshToken,
+		OnLineCommand:          cfg.OnLineCommand,
+		PlayerSelection:        cfg.PlayerSelection,
+		QuietHoursStart:        cfg.QuietHoursStart,
+		QuietHoursEnd:          cfg.QuietHoursEnd,
+		StripAnnotations:       cfg.StripAnnotations,
+		AnnotationPatterns:     cfg.AnnotationPatterns,
+		LyricsRateLimit:        cfg.LyricsRateLimit,
+		HideLineLog:            *tuiMode,
+		IgnoredPlayers:         cfg.IgnoredPlayers,
+		ClipboardMinInterval:   cfg.ClipboardMinInterval,
+		StartupDelay:           cfg.StartupDelay,
+		MaxLineLength:          cfg.MaxLineLength,
+		ClearClipboardOnNoSong: cfg.ClearClipboardOnNoSong,
+		NoSongText:             cfg.NoSongText,
+		DetectDrift:            cfg.DetectDrift,
+		AutoOffset:             cfg.AutoOffset,
+		ClipboardHistoryLines:  cfg.ClipboardHistoryLines,
+		PlayerctlFallback:      cfg.PlayerctlFallback,
+		SkipLongTracksMinutes:  cfg.SkipLongTracksMinutes,
+		FIFOPath:               cfg.FIFOPath,
+		ForceRewriteDuplicates: cfg.ForceRewriteDuplicates,
+		LocalDBPath:            cfg.LocalDBPath,
+		CopyOnDemand:           cfg.CopyOnDemand,
 	}
 
 	orch, err := orchestrator.NewOrchestrator(orchConfig)
@@ -62,22 +180,84 @@ func main() {
 		log.Fatalf("Failed to create orchestrator: %v", err)
 	}
 
-	if cfg.DemoMode {
+	if cfg.DemoMode && cfg.DemoLRC != "" {
+		log.Printf("Running in DEMO mode with lyrics from %s", cfg.DemoLRC)
+	} else if cfg.DemoMode {
 		log.Printf("Running in DEMO mode with: %s - %s", cfg.DemoArtist, cfg.DemoTitle)
 	}
 	if cfg.LyricOffset != 0 {
 		log.Printf("Lyric offset: %v", cfg.LyricOffset)
 	}
 
+	// Start the control API if configured
+	if cfg.ControlSocket != "" {
+		server := control.NewServer(orch, cfg.ControlSocket)
+		if err := server.Start(); err != nil {
+			log.Printf("Failed to start control API: %v", err)
+		} else {
+			defer server.Close()
+		}
+	}
+
+	// Start the HTTP API if configured
+	if cfg.HTTPAddr != "" {
+		server := httpapi.NewServer(orch, cfg.HTTPAddr)
+		if err := server.Start(); err != nil {
+			log.Printf("Failed to start HTTP API: %v", err)
+		} else {
+			defer server.Close()
+		}
+	}
+
+	// Register global hotkeys if enabled
+	if cfg.EnableHotkeys {
+		hotkeys, err := hotkey.Listen([]hotkey.Binding{
+			{Keys: cfg.HotkeyOffsetBack, Action: func() { orch.AdjustLyricOffset(-hotkeyOffsetStep) }},
+			{Keys: cfg.HotkeyOffsetForward, Action: func() { orch.AdjustLyricOffset(hotkeyOffsetStep) }},
+			{Keys: cfg.HotkeyPause, Action: func() { orch.TogglePause() }},
+			{Keys: cfg.HotkeyCopyLine, Action: func() {
+				if err := orch.CopyCurrentLine(); err != nil {
+					log.Printf("Failed to copy line: %v", err)
+				}
+			}},
+		})
+		if err != nil {
+			log.Printf("Global hotkeys unavailable: %v", err)
+		} else {
+			defer hotkeys.Close()
+		}
+	}
+
+	// Show the current song on Discord if enabled
+	if cfg.DiscordRPC {
+		if cfg.DiscordClientID == "" {
+			log.Println("Discord Rich Presence is enabled but discord_client_id is not set")
+		} else {
+			presence := discord.NewPresence(orch, cfg.DiscordClientID)
+			presence.Start()
+			defer presence.Close()
+		}
+	}
+
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
+	// Keep the current line on screen, with log messages above it
+	var display *tui.Display
+	if *tuiMode {
+		display = tui.New(orch)
+		display.Start()
+	}
+
 	// Start orchestrator in a goroutine
 	go orch.Start()
 
 	// Wait for shutdown signal
 	<-sigChan
+	if display != nil {
+		display.Close()
+	}
 	log.Println("\nReceived shutdown signal...")
 
 	// Stop orchestrator
@@ -85,3 +265,280 @@ func main() {
 
 	log.Println("Goodbye!")
 }
+
+// runValidate checks the configuration file and prints the problems found
+// along with the effective configuration. Returns the process exit code.
+func runValidate(configPath string) int {
+	path, err := config.ResolvePath(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	cfg, problems, err := config.CheckFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Config file: %s\n", path)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		fmt.Println("File does not exist, using defaults")
+	}
+
+	data, err := cfg.JSON()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("\nEffective configuration:\n%s\n", data)
+
+	if len(problems) == 0 {
+		fmt.Println("\nConfiguration is valid")
+		return 0
+	}
+
+	fmt.Printf("\nFound %d problem(s):\n", len(problems))
+	for _, problem := range problems {
+		fmt.Printf("  - %s\n", problem)
+	}
+	return 1
+}
+
+// calibrationSamples is how many position readings -calibrate takes
+const calibrationSamples = 20
+
+// runCalibrate measures how stale the player's reported position is and
+// prints a recommended lyric offset. Returns the process exit code.
+func runCalibrate(cfg *config.Config) int {
+	var det detector.Detector
+	if cfg.DemoMode {
+		det = detector.NewDemoDetector(cfg.DemoArtist, cfg.DemoTitle)
+	} else {
+		var err error
+		det, err = detector.NewDetector(detector.Options{
+			PreferredPlayers:  cfg.PreferredPlayers,
+			PlayerSelection:   cfg.PlayerSelection,
+			SplitStreamTitles: cfg.RadioMode,
+			IgnoredPlayers:    cfg.IgnoredPlayers,
+			PlayerctlFallback: cfg.PlayerctlFallback,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	defer det.Close()
+
+	duration := time.Duration(calibrationSamples-1) * cfg.PollInterval
+	fmt.Printf("Calibrating for about %v, keep a track playing without seeking...\n", duration.Round(time.Second))
+
+	result, err := detector.Calibrate(det, calibrationSamples, cfg.PollInterval)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	// With fixed polling, a line change is noticed half an interval late on average
+	recommended := result.AverageStaleness
+	if !cfg.AdaptivePolling {
+		recommended += cfg.PollInterval / 2
+	}
+
+	fmt.Printf("Samples:           %d\n", result.Samples)
+	fmt.Printf("Average staleness: %v\n", result.AverageStaleness.Round(time.Millisecond))
+	fmt.Printf("Maximum staleness: %v\n", result.MaxStaleness.Round(time.Millisecond))
+	fmt.Printf("Current offset:    %d ms\n", cfg.LyricOffset.Milliseconds())
+	fmt.Printf("\nRecommended lyric_offset_ms: %d\n", recommended.Milliseconds())
+	return 0
+}
+
+// doctorClipboardText is written to the clipboard by -doctor to check that
+// it reads back unchanged
+const doctorClipboardText = "lyric-clipboard self-test ♪"
+
+// runDoctor checks each part of the setup the app depends on and prints
+// whether it passed. Returns the process exit code, 1 if any check failed.
+func runDoctor(cfg *config.Config) int {
+	checks := []struct {
+		name string
+		run  func() (string, error)
+	}{
+		{"Clipboard", func() (string, error) { return doctorClipboard(cfg) }},
+		{"Player detection", func() (string, error) { return doctorDetector(cfg) }},
+		{"lrclib.net", func() (string, error) { return doctorLyrics(cfg) }},
+	}
+
+	failed := 0
+	for _, check := range checks {
+		detail, err := check.run()
+		if err != nil {
+			failed++
+			fmt.Printf("FAIL  %-17s %v\n", check.name, err)
+			continue
+		}
+		fmt.Printf("PASS  %-17s %s\n", check.name, detail)
+	}
+
+	if failed > 0 {
+		fmt.Printf("\n%d of %d checks failed\n", failed, len(checks))
+		return 1
+	}
+	fmt.Println("\nAll checks passed")
+	return 0
+}
+
+// doctorClipboard writes to the clipboard and reads it back, restoring the
+// previous contents afterwards
+func doctorClipboard(cfg *config.Config) (string, error) {
+	manager, err := clipboard.NewManager(clipboard.Options{
+		Backend:        cfg.ClipboardBackend,
+		CommandTimeout: cfg.ClipboardTimeout,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	previous, readErr := manager.Read()
+	if err := manager.Write(doctorClipboardText); err != nil {
+		return "", fmt.Errorf("write failed: %w", err)
+	}
+	if readErr == nil {
+		defer manager.Write(previous)
+	}
+
+	text, err := manager.Read()
+	if err != nil {
+		return "", fmt.Errorf("read failed: %w", err)
+	}
+	if text != doctorClipboardText {
+		return "", fmt.Errorf("read back %q, expected %q", text, doctorClipboardText)
+	}
+	return "write and read back succeeded", nil
+}
+
+// doctorDetector connects to the platform's media system and asks it for
+// the current song
+func doctorDetector(cfg *config.Config) (string, error) {
+	det, err := detector.NewDetector(detector.Options{
+		PreferredPlayers:  cfg.PreferredPlayers,
+		PlayerSelection:   cfg.PlayerSelection,
+		SplitStreamTitles: cfg.RadioMode,
+		IgnoredPlayers:    cfg.IgnoredPlayers,
+		PlayerctlFallback: cfg.PlayerctlFallback,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer det.Close()
+
+	if hc, ok := det.(detector.HealthChecker); ok && !hc.Healthy() {
+		return "", fmt.Errorf("media system is unavailable")
+	}
+
+	song, err := det.GetCurrentSong()
+	if errors.Is(err, detector.ErrNoSong) {
+		return "available, nothing playing", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("available, playing %s - %s", song.Artist, song.Title), nil
+}
+
+// doctorLyrics checks that the lyrics API is reachable
+func doctorLyrics(cfg *config.Config) (string, error) {
+	fetcher := lyrics.NewFetcher()
+	if cfg.UserAgent != "" {
+		fetcher.SetUserAgent(cfg.UserAgent)
+	}
+	if cfg.FetchTimeout != 0 {
+		fetcher.SetFetchTimeout(cfg.FetchTimeout)
+	}
+
+	start := time.Now()
+	if err := fetcher.Ping(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("reachable in %v", time.Since(start).Round(time.Millisecond)), nil
+}
+
+// runDumpLyrics fetches a song's lyrics like the app does and prints each
+// line with its time. With raw, the lyrics are fetched from lrclib.net
+// alone, bypassing the cache and the local database, and printed unparsed.
+// Returns the process exit code.
+func runDumpLyrics(cfg *config.Config, artist, title string, raw bool) int {
+	if artist == "" || title == "" {
+		fmt.Fprintln(os.Stderr, "Error: -dump-lyrics needs -artist and -title")
+		return 2
+	}
+
+	fetcher := lyrics.NewFetcher()
+	if cfg.UserAgent != "" {
+		fetcher.SetUserAgent(cfg.UserAgent)
+	}
+	if cfg.FetchTimeout != 0 {
+		fetcher.SetFetchTimeout(cfg.FetchTimeout)
+	}
+	if raw {
+		_, text, err := fetcher.FetchLyricsBypassCache(artist, title)
+		if text == "" && err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Println(text)
+		return 0
+	}
+	if cfg.LocalDBPath != "" {
+		db, err := lyrics.OpenLocalDB(cfg.LocalDBPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: not using the local lyrics database: %v\n", err)
+		} else {
+			fetcher.SetLocalDB(db)
+		}
+	}
+
+	songLyrics, err := fetcher.FetchLyrics(artist, title, "", 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	switch {
+	case songLyrics.Instrumental:
+		fmt.Println("Instrumental track, no lyrics")
+	case len(songLyrics.Lines) == 0:
+		fmt.Println("No synced lyrics, plain lyrics:")
+		fmt.Println(songLyrics.Plain)
+	default:
+		for _, line := range songLyrics.Lines {
+			cs := line.Time.Milliseconds() / 10
+			fmt.Printf("%02d:%02d.%02d  %s\n", cs/6000, cs/100%60, cs%100, line.Text)
+		}
+	}
+	return 0
+}
+
+// runListPlayers prints the media players visible to the detector.
+// Returns the process exit code.
+func runListPlayers() int {
+	players, err := detector.ListPlayers()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if len(players) == 0 {
+		fmt.Println("No media players found")
+		return 0
+	}
+
+	for _, player := range players {
+		track := "(no track)"
+		if player.Title != "" {
+			track = fmt.Sprintf("%s - %s", player.Artist, player.Title)
+		}
+		fmt.Printf("%-45s %-8s %s\n", player.Name, player.Status, track)
+	}
+	return 0
+}
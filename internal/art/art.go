@@ -0,0 +1,165 @@
+// Package art loads cover art from the URLs players report, which are
+// file:// paths to images they cached or http(s) URLs.
+package art
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// maxEntries is how many images are kept in memory. Only the current
+	// track's is normally needed, this just avoids refetching on skips back.
+	maxEntries = 8
+	// maxSize is the largest image downloaded
+	maxSize = 10 << 20
+	// fetchTimeout limits downloading an image
+	fetchTimeout = 10 * time.Second
+)
+
+// Cache loads cover art and keeps the most recent images
+type Cache struct {
+	client *http.Client
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	order   []string // URLs, oldest first
+}
+
+// entry is a cached image, and its local path once one was needed
+type entry struct {
+	data []byte
+	path string
+}
+
+// NewCache creates an empty cover art cache
+func NewCache() *Cache {
+	return &Cache{
+		client:  &http.Client{Timeout: fetchTimeout},
+		entries: make(map[string]*entry),
+	}
+}
+
+// Load returns the image at artURL
+func (c *Cache) Load(artURL string) ([]byte, error) {
+	e, err := c.get(artURL)
+	if err != nil {
+		return nil, err
+	}
+	return e.data, nil
+}
+
+// File returns a local path of the image at artURL, for notification
+// commands that take an image file. Downloaded images are written to the
+// temporary directory.
+func (c *Cache) File(artURL string) (string, error) {
+	if path, ok := filePath(artURL); ok {
+		return path, nil
+	}
+
+	e, err := c.get(artURL)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e.path != "" {
+		return e.path, nil
+	}
+
+	sum := sha1.Sum([]byte(artURL))
+	path := filepath.Join(os.TempDir(), "lyric-clipboard-art-"+hex.EncodeToString(sum[:]))
+	if err := os.WriteFile(path, e.data, 0600); err != nil {
+		return "", fmt.Errorf("failed to save cover art: %w", err)
+	}
+	e.path = path
+	return path, nil
+}
+
+// get returns the cached entry for artURL, loading it if needed
+func (c *Cache) get(artURL string) (*entry, error) {
+	c.mu.Lock()
+	e, ok := c.entries[artURL]
+	c.mu.Unlock()
+	if ok {
+		return e, nil
+	}
+
+	data, err := c.fetch(artURL)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[artURL]; ok {
+		// Loaded by another caller in the meantime
+		return e, nil
+	}
+	e = &entry{data: data}
+	c.entries[artURL] = e
+	c.order = append(c.order, artURL)
+	if len(c.order) > maxEntries {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	return e, nil
+}
+
+// fetch reads the image at artURL from disk or over HTTP
+func (c *Cache) fetch(artURL string) ([]byte, error) {
+	if path, ok := filePath(artURL); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cover art: %w", err)
+		}
+		return data, nil
+	}
+
+	u, err := url.Parse(artURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("unsupported cover art URL %q", artURL)
+	}
+
+	resp, err := c.client.Get(artURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch cover art: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch cover art: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch cover art: %w", err)
+	}
+	if len(data) > maxSize {
+		return nil, fmt.Errorf("cover art is larger than %d bytes", maxSize)
+	}
+	return data, nil
+}
+
+// filePath returns the local path of a file:// URL
+func filePath(artURL string) (string, bool) {
+	u, err := url.Parse(artURL)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	path := u.Path
+	// file:///C:/... on Windows
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path), true
+}
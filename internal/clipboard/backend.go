@@ -0,0 +1,175 @@
+package clipboard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/atotto/clipboard"
+)
+
+// Backend is a way of accessing the system clipboard
+type Backend interface {
+	// Write replaces the clipboard contents with text
+	Write(text string) error
+	// Read returns the clipboard contents
+	Read() (string, error)
+	// Available reports whether the backend can be used on this system
+	Available() bool
+}
+
+// DefaultCommandTimeout is how long clipboard commands may run before they
+// are killed
+const DefaultCommandTimeout = 2 * time.Second
+
+// ErrTimeout is returned when a clipboard command doesn't finish in time,
+// e.g. xclip waiting on an X server that never answers
+var ErrTimeout = errors.New("clipboard command timed out")
+
+// Backend names accepted in Options
+const (
+	BackendXClip       = "xclip"
+	BackendWLClipboard = "wl-clipboard"
+	BackendPBCopy      = "pbcopy"
+	BackendAtotto      = "atotto"
+)
+
+// backendOrder lists backends from most to least preferred when probing
+var backendOrder = []string{BackendWLClipboard, BackendXClip, BackendPBCopy, BackendAtotto}
+
+// backendByName returns the backend with the given name. Command-line
+// backends are killed after timeout.
+func backendByName(name string, timeout time.Duration) (Backend, error) {
+	switch name {
+	case BackendXClip:
+		return xclipBackend{timeout}, nil
+	case BackendWLClipboard:
+		return wlClipboardBackend{timeout}, nil
+	case BackendPBCopy:
+		return pbcopyBackend{timeout}, nil
+	case BackendAtotto:
+		return atottoBackend{}, nil
+	default:
+		return nil, fmt.Errorf("unknown clipboard backend %q", name)
+	}
+}
+
+// xclipBackend uses xclip, which also works under WSL
+type xclipBackend struct {
+	timeout time.Duration
+}
+
+func (b xclipBackend) Write(text string) error {
+	return runWrite(b.timeout, "xclip", text, "-selection", "clipboard")
+}
+
+func (b xclipBackend) Read() (string, error) {
+	return runRead(b.timeout, "xclip", "-selection", "clipboard", "-o")
+}
+
+func (xclipBackend) Available() bool {
+	return hasCommand("xclip")
+}
+
+// wlClipboardBackend uses wl-copy and wl-paste on Wayland
+type wlClipboardBackend struct {
+	timeout time.Duration
+}
+
+func (b wlClipboardBackend) Write(text string) error {
+	return runWrite(b.timeout, "wl-copy", text)
+}
+
+func (b wlClipboardBackend) Read() (string, error) {
+	return runRead(b.timeout, "wl-paste", "--no-newline")
+}
+
+func (wlClipboardBackend) Available() bool {
+	return os.Getenv("WAYLAND_DISPLAY") != "" && hasCommand("wl-copy") && hasCommand("wl-paste")
+}
+
+// pbcopyBackend uses pbcopy and pbpaste on macOS
+type pbcopyBackend struct {
+	timeout time.Duration
+}
+
+func (b pbcopyBackend) Write(text string) error {
+	return runWrite(b.timeout, "pbcopy", text)
+}
+
+func (b pbcopyBackend) Read() (string, error) {
+	return runRead(b.timeout, "pbpaste")
+}
+
+func (pbcopyBackend) Available() bool {
+	return hasCommand("pbcopy") && hasCommand("pbpaste")
+}
+
+// atottoBackend uses github.com/atotto/clipboard, which picks a platform
+// mechanism itself
+type atottoBackend struct{}
+
+func (atottoBackend) Write(text string) error {
+	if err := clipboard.WriteAll(text); err != nil {
+		return fmt.Errorf("failed to write to clipboard: %w", err)
+	}
+	return nil
+}
+
+func (atottoBackend) Read() (string, error) {
+	text, err := clipboard.ReadAll()
+	if err != nil {
+		return "", fmt.Errorf("failed to read from clipboard: %w", err)
+	}
+	return text, nil
+}
+
+func (atottoBackend) Available() bool {
+	return !clipboard.Unsupported
+}
+
+// hasCommand reports whether an executable is on the PATH
+func hasCommand(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// runWrite runs a command with text as its standard input, killing it if it
+// runs longer than timeout
+func runWrite(timeout time.Duration, name, text string, args ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = bytes.NewBufferString(text)
+	cmd.WaitDelay = timeout // Don't wait on children still holding our pipes
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("%s: %w after %v", name, ErrTimeout, timeout)
+		}
+		return fmt.Errorf("%s failed: %w", name, err)
+	}
+	return nil
+}
+
+// runRead runs a command and returns its standard output, killing it if it
+// runs longer than timeout
+func runRead(timeout time.Duration, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = timeout
+	output, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("%s: %w after %v", name, ErrTimeout, timeout)
+		}
+		return "", fmt.Errorf("%s read failed: %w", name, err)
+	}
+	return string(output), nil
+}
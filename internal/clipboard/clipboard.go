@@ -1,68 +1,78 @@
 package clipboard
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
-	"os/exec"
-	"runtime"
-
-	"github.com/atotto/clipboard"
+	"log"
+	"time"
 )
 
+// Options configures a Manager
+type Options struct {
+	// Backend forces a backend by name, e.g. BackendXClip. Empty probes for
+	// the best available one.
+	Backend string
+
+	// CommandTimeout limits how long command-line backends may run.
+	// Defaults to DefaultCommandTimeout.
+	CommandTimeout time.Duration
+}
+
 // Manager handles clipboard operations
 type Manager struct {
-	useXClip bool
+	backend Backend
 }
 
-// NewManager creates a new clipboard manager
-func NewManager() *Manager {
-	m := &Manager{}
+// NewManager creates a new clipboard manager. Without a forced backend it
+// uses the first available one, preferring command-line tools over the
+// atotto fallback.
+func NewManager(opts Options) (*Manager, error) {
+	timeout := opts.CommandTimeout
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+
+	if opts.Backend != "" {
+		backend, err := backendByName(opts.Backend, timeout)
+		if err != nil {
+			return nil, err
+		}
+		if !backend.Available() {
+			return nil, fmt.Errorf("clipboard backend %q is not available", opts.Backend)
+		}
+		return NewManagerWith(backend), nil
+	}
 
-	// On Linux, check if xclip is available (for WSL compatibility)
-	if runtime.GOOS == "linux" {
-		if _, err := exec.LookPath("xclip"); err == nil {
-			m.useXClip = true
+	for _, name := range backendOrder {
+		backend, _ := backendByName(name, timeout)
+		if backend.Available() {
+			return NewManagerWith(backend), nil
 		}
 	}
 
-	return m
+	// Nothing reported itself available; atotto will report why on use
+	return NewManagerWith(atottoBackend{}), nil
 }
 
-// Write writes text to the system clipboard
+// NewManagerWith creates a clipboard manager using the given backend
+func NewManagerWith(backend Backend) *Manager {
+	return &Manager{backend: backend}
+}
+
+// Write writes text to the system clipboard. If a command-line backend
+// hangs, the write is retried with atotto.
 func (m *Manager) Write(text string) error {
-	// Try xclip first on Linux if available (WSL compatible)
-	if m.useXClip {
-		cmd := exec.Command("xclip", "-selection", "clipboard")
-		cmd.Stdin = bytes.NewBufferString(text)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("xclip failed: %w", err)
+	err := m.backend.Write(text)
+	if errors.Is(err, ErrTimeout) {
+		if _, isAtotto := m.backend.(atottoBackend); !isAtotto {
+			log.Printf("%v, falling back to atotto", err)
+			return atottoBackend{}.Write(text)
 		}
-		return nil
 	}
-
-	// Fallback to atotto/clipboard
-	if err := clipboard.WriteAll(text); err != nil {
-		return fmt.Errorf("failed to write to clipboard: %w", err)
-	}
-	return nil
+	return err
 }
 
 // Read reads text from the system clipboard
 func (m *Manager) Read() (string, error) {
-	// Try xclip first on Linux if available
-	if m.useXClip {
-		cmd := exec.Command("xclip", "-selection", "clipboard", "-o")
-		output, err := cmd.Output()
-		if err != nil {
-			return "", fmt.Errorf("xclip read failed: %w", err)
-		}
-		return string(output), nil
-	}
-
-	// Fallback to atotto/clipboard
-	text, err := clipboard.ReadAll()
-	if err != nil {
-		return "", fmt.Errorf("failed to read from clipboard: %w", err)
-	}
-	return text, nil
+	return m.backend.Read()
 }
@@ -0,0 +1,185 @@
+// Package clock abstracts the current time and timers, so the demo detector
+// and the orchestrator's loop can be driven by a fake clock in tests instead
+// of waiting in real time.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time and creates timers
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is the part of time.Timer used by the orchestrator
+type Timer interface {
+	C() <-chan time.Time
+	Reset(d time.Duration) bool
+	Stop() bool
+}
+
+// Real is the system clock
+var Real Clock = realClock{}
+
+// realClock is a Clock backed by the time package
+type realClock struct{}
+
+// Now returns the current time
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer creates a timer that fires after d
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+// AfterFunc calls f in its own goroutine after d. The returned timer's
+// channel is unused.
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return realTimer{time.AfterFunc(d, f)}
+}
+
+// realTimer adapts time.Timer to the Timer interface
+type realTimer struct {
+	*time.Timer
+}
+
+// C returns the channel the timer fires on
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// Fake is a Clock whose time only moves when Advance is called. Timers fire
+// as Advance passes their deadlines.
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFake creates a fake clock set to start
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+// Now returns the fake clock's current time
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// NewTimer creates a timer that fires once the clock has advanced by d
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &fakeTimer{clock: f, c: make(chan time.Time, 1)}
+	t.arm(f.now.Add(d))
+	return t
+}
+
+// AfterFunc calls f in its own goroutine once the clock has advanced by d
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &fakeTimer{clock: f, c: make(chan time.Time, 1), fn: fn}
+	t.arm(f.now.Add(d))
+	return t
+}
+
+// Advance moves the clock forward by d, firing the timers due by then.
+// A timer that is reset while firing fires again if its new deadline is
+// also within d.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	end := f.now.Add(d)
+	f.mu.Unlock()
+
+	for {
+		f.mu.Lock()
+		next := f.nextTimer(end)
+		if next == nil {
+			f.now = end
+			f.mu.Unlock()
+			return
+		}
+		f.now = next.deadline
+		next.active = false
+		f.mu.Unlock()
+
+		if next.fn != nil {
+			go next.fn()
+			continue
+		}
+		// Like time.Timer, don't block if the last tick wasn't received
+		select {
+		case next.c <- next.deadline:
+		default:
+		}
+	}
+}
+
+// nextTimer returns the active timer with the earliest deadline not after
+// end, or nil if there is none. The caller holds f.mu.
+func (f *Fake) nextTimer(end time.Time) *fakeTimer {
+	var next *fakeTimer
+	for _, t := range f.timers {
+		if t.active && !t.deadline.After(end) && (next == nil || t.deadline.Before(next.deadline)) {
+			next = t
+		}
+	}
+	return next
+}
+
+// fakeTimer is a Timer driven by a Fake clock
+type fakeTimer struct {
+	clock    *Fake
+	c        chan time.Time
+	deadline time.Time
+	active   bool
+	listed   bool   // In the clock's timer list
+	fn       func() // Called instead of sending on c, see AfterFunc
+}
+
+// arm schedules the timer for deadline. The caller holds the clock's mutex.
+func (t *fakeTimer) arm(deadline time.Time) {
+	if !t.listed {
+		t.clock.timers = append(t.clock.timers, t)
+		t.listed = true
+	}
+	t.deadline = deadline
+	t.active = true
+}
+
+// C returns the channel the timer fires on
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+// Reset schedules the timer to fire d after the clock's current time,
+// reporting whether it was still active
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	wasActive := t.active
+	t.arm(t.clock.now.Add(d))
+	return wasActive
+}
+
+// Stop stops the timer, reporting whether it was still active
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	wasActive := t.active
+	t.active = false
+	return wasActive
+}
@@ -1,7 +1,6 @@
 package config
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,63 +10,261 @@ import (
 // Config represents the application configuration
 type Config struct {
 	// General settings
-	PollInterval time.Duration `json:"poll_interval"` // How often to check for song updates (in milliseconds)
+	PollInterval    time.Duration `json:"poll_interval"`    // How often to check for song updates (in milliseconds)
+	StartupDelay    time.Duration `json:"startup_delay"`    // Wait this long before the first detection, e.g. when started at login (in milliseconds)
+	AdaptivePolling bool          `json:"adaptive_polling"` // Poll just before the next lyric line instead of at a fixed interval
+	Debug           bool          `json:"debug"`            // Log extra detail for tracking down problems, such as ignored player readings
 
 	// Lyrics settings
-	LyricOffset  time.Duration `json:"lyric_offset"`  // Time offset to apply to lyrics (in milliseconds)
-	EnableCache  bool          `json:"enable_cache"`  // Enable lyrics caching
+	LyricOffset        time.Duration `json:"lyric_offset"`        // Time offset to apply to lyrics (in milliseconds)
+	DetectDrift        bool          `json:"detect_drift"`        // Log a suggested offset when lyrics drift out of time over a track
+	AutoOffset         bool          `json:"auto_offset"`         // Correct drift automatically, within a bounded range; implies detect_drift
+	LeadTime           time.Duration `json:"lead_time"`           // Show the next line up to this early so it can be read ahead (in milliseconds)
+	EnableCache        bool          `json:"enable_cache"`        // Enable lyrics caching
+	CacheMaxEntries    int           `json:"cache_max_entries"`   // Maximum number of songs kept in the lyrics cache (negative for no limit)
+	NegativeCacheTTL   time.Duration `json:"negative_cache_ttl"`  // How long to remember songs without lyrics (in minutes, negative to disable)
+	Romanize           bool          `json:"romanize"`            // Copy romanized text for non-Latin lyrics when available
+	StripAnnotations   bool          `json:"strip_annotations"`   // Remove annotations such as *chorus* or [Verse 1] from lyric lines
+	AnnotationPatterns []string      `json:"annotation_patterns"` // Regular expressions for annotations to strip (empty for the built-in patterns)
+	ShowTranslation    bool          `json:"show_translation"`    // Include translated lyrics on the clipboard when available
+	TranslationDir     string        `json:"translation_dir"`     // Directory of translated "Artist - Title.lrc" files
+	UserAgent          string        `json:"user_agent"`          // User-Agent sent to lyrics APIs (empty for the built-in default)
+	FetchTimeout       time.Duration `json:"fetch_timeout"`       // Give up on a lyrics lookup, including retries, after this long (in milliseconds)
+	LyricsRateLimit    float64       `json:"lyrics_rate_limit"`   // Maximum lyrics API requests per second (negative for no limit)
+	LocalDBPath        string        `json:"local_db_path"`       // lrclib SQLite database dump searched before lrclib.net, needs the sqlite3 tool (empty to disable)
 
 	// Clipboard settings
-	UpdateClipboard bool `json:"update_clipboard"` // Enable clipboard updates
+	UpdateClipboard        bool          `json:"update_clipboard"`           // Enable clipboard updates
+	CopyOnDemand           bool          `json:"copy_on_demand"`             // Only copy the current line when asked to, with hotkey_copy_line, the tray or the control API
+	ClipboardTemplate      string        `json:"clipboard_template"`         // Go template for clipboard text, e.g. "{{.Line}}\n{{.Translation}}"
+	IncludeTimestamp       bool          `json:"include_timestamp"`          // Prefix copied lines with their timestamp, e.g. "[01:23] "
+	ClipboardMode          string        `json:"clipboard_mode"`             // "replace" to overwrite the clipboard, "append" to add each line to it
+	ClipboardMaxLength     int           `json:"clipboard_max_length"`       // In append mode, trim the oldest lines beyond this many bytes (0 for no limit)
+	MaxLineLength          int           `json:"max_line_length"`            // Cut clipboard text longer than this many characters, ending it with an ellipsis (0 for no limit)
+	ClipboardHistoryLines  int           `json:"clipboard_history_lines"`    // Keep this many of the latest lines on the clipboard, newest last (0 or 1 for just the current line)
+	ForceRewriteDuplicates bool          `json:"force_rewrite_duplicates"`   // Write a repeated line again when it comes up at a new timestamp, e.g. a chorus, for tools watching clipboard changes
+	QuietHoursStart        string        `json:"quiet_hours_start"`          // Time of day ("HH:MM") from which the clipboard is left alone, e.g. "09:00" (empty to disable)
+	QuietHoursEnd          string        `json:"quiet_hours_end"`            // Time of day ("HH:MM") at which clipboard updates resume; may be past midnight
+	ClearOnTrackEnd        bool          `json:"clear_on_track_end"`         // Clear the clipboard when a track finishes
+	ClearClipboardOnNoSong bool          `json:"clear_clipboard_on_no_song"` // Replace the last lyric with no_song_text when playback stops, unless the clipboard was changed since
+	NoSongText             string        `json:"no_song_text"`               // Clipboard text when no song is playing, empty to clear it
+	TrackEndWindow         time.Duration `json:"track_end_window"`           // How close to the end a track counts as finished (in milliseconds)
+	ClipboardBackend       string        `json:"clipboard_backend"`          // Force "xclip", "wl-clipboard", "pbcopy" or "atotto" (empty to detect)
+	ClipboardTimeout       time.Duration `json:"clipboard_timeout"`          // How long clipboard commands like xclip may run (in milliseconds)
+	InstrumentalText       string        `json:"instrumental_text"`          // Clipboard text for tracks without vocals
+	ClipboardDebounce      time.Duration `json:"clipboard_debounce"`         // Coalesce line changes within this window into one write (in milliseconds, 0 to disable)
+	ClipboardMinInterval   time.Duration `json:"clipboard_min_interval"`     // Write to the clipboard at most once per interval, for clipboard history managers (in milliseconds, 0 to disable)
+	MinLineDisplay         time.Duration `json:"min_line_display"`           // Keep each line on the clipboard at least this long (in milliseconds, 0 to disable)
 
 	// Demo mode settings
 	DemoMode   bool   `json:"demo_mode"`   // Run in demo mode
 	DemoArtist string `json:"demo_artist"` // Artist for demo mode
 	DemoTitle  string `json:"demo_title"`  // Title for demo mode
+	DemoLRC    string `json:"demo_lrc"`    // LRC or SRT file to play offline in demo mode (empty to fetch lyrics for the demo song)
 
 	// GUI settings
-	StartMinimized bool `json:"start_minimized"` // Start app minimized to system tray
-	ShowNotifications bool `json:"show_notifications"` // Show notifications for song changes
+	StartMinimized    bool   `json:"start_minimized"`    // Start app minimized to system tray
+	ShowNotifications bool   `json:"show_notifications"` // Show notifications for song changes
+	IconTheme         string `json:"icon_theme"`         // Tray icon variant: "auto", "light" or "dark" to match the tray background
+
+	// Detection settings
+	PreferredPlayers      []string `json:"preferred_players"`        // Players to check first, e.g. "spotify"
+	IgnoredPlayers        []string `json:"ignored_players"`          // Players never followed, e.g. "firefox" or "chromium.*"
+	PlayerctlFallback     bool     `json:"playerctl_fallback"`       // Ask playerctl for the song when none is found over D-Bus (Linux only)
+	PlayerSelection       string   `json:"player_selection"`         // How to choose among several playing players: "first", "recent" or "preferred"
+	MaxDetectorFailures   int      `json:"max_detector_failures"`    // Consecutive detection failures before the current song is forgotten
+	RadioMode             bool     `json:"radio_mode"`               // Split "Artist - Title" stream titles and show the song name for streams without a length
+	SkipLongTracksMinutes int      `json:"skip_long_tracks_minutes"` // Treat tracks at least this long as podcasts or audiobooks and skip the lyrics lookup (0 to disable)
+
+	// Integration settings
+	ControlSocket   string `json:"control_socket"`    // Unix socket path for the control API (empty to disable)
+	HTTPAddr        string `json:"http_addr"`         // Address for the HTTP API and WebSocket stream, e.g. "127.0.0.1:8973" (empty to disable)
+	DiscordRPC      bool   `json:"discord_rpc"`       // Show the current song and line as Discord Rich Presence
+	DiscordClientID string `json:"discord_client_id"` // Discord application client ID used for Rich Presence
+	OnLineCommand   string `json:"on_line_command"`   // Shell command run on every line change, e.g. "notify-send {{.Artist}} {{.Line}}" (empty to disable)
+	FIFOPath        string `json:"fifo_path"`         // Named pipe to write each lyric line to, for status bars (empty to disable, not on Windows)
+
+	// Hotkey settings
+	EnableHotkeys       bool   `json:"enable_hotkeys"`        // Register global hotkeys for the offset and pausing
+	HotkeyOffsetBack    string `json:"hotkey_offset_back"`    // Hotkey that delays lyrics by 100ms, e.g. "ctrl+alt+left"
+	HotkeyOffsetForward string `json:"hotkey_offset_forward"` // Hotkey that advances lyrics by 100ms
+	HotkeyPause         string `json:"hotkey_pause"`          // Hotkey that pauses and resumes the app
+	HotkeyCopyLine      string `json:"hotkey_copy_line"`      // Hotkey that copies the current line
+
+	// Spotify settings, for detecting songs played on other devices
+	SpotifyToken        string `json:"spotify_token"`         // Web API access token with the user-read-currently-playing scope
+	SpotifyClientID     string `json:"spotify_client_id"`     // Spotify app client ID, used with the refresh token to renew access tokens
+	SpotifyClientSecret string `json:"spotify_client_secret"` // Spotify app client secret (empty for apps using PKCE)
+	SpotifyRefreshToken string `json:"spotify_refresh_token"` // Spotify refresh token
 }
 
-// configFile represents the JSON structure for the config file
+// CurrentVersion is the config file format version written by Save. Files
+// without a version predate versioning.
+const CurrentVersion = 1
+
+// configFile represents the on-disk structure of the config file, shared by
+// the JSON, TOML and YAML formats
 type configFile struct {
-	PollIntervalMs    int    `json:"poll_interval_ms"`
-	LyricOffsetMs     int    `json:"lyric_offset_ms"`
-	EnableCache       bool   `json:"enable_cache"`
-	UpdateClipboard   bool   `json:"update_clipboard"`
-	DemoMode          bool   `json:"demo_mode"`
-	DemoArtist        string `json:"demo_artist"`
-	DemoTitle         string `json:"demo_title"`
-	StartMinimized    bool   `json:"start_minimized"`
-	ShowNotifications bool   `json:"show_notifications"`
+	Version                 int      `json:"version" toml:"version" yaml:"version"`
+	PollIntervalMs          int      `json:"poll_interval_ms" toml:"poll_interval_ms" yaml:"poll_interval_ms"`
+	LyricOffsetMs           int      `json:"lyric_offset_ms" toml:"lyric_offset_ms" yaml:"lyric_offset_ms"`
+	EnableCache             bool     `json:"enable_cache" toml:"enable_cache" yaml:"enable_cache"`
+	UpdateClipboard         bool     `json:"update_clipboard" toml:"update_clipboard" yaml:"update_clipboard"`
+	DemoMode                bool     `json:"demo_mode" toml:"demo_mode" yaml:"demo_mode"`
+	DemoArtist              string   `json:"demo_artist" toml:"demo_artist" yaml:"demo_artist"`
+	DemoTitle               string   `json:"demo_title" toml:"demo_title" yaml:"demo_title"`
+	StartMinimized          bool     `json:"start_minimized" toml:"start_minimized" yaml:"start_minimized"`
+	ShowNotifications       bool     `json:"show_notifications" toml:"show_notifications" yaml:"show_notifications"`
+	AdaptivePolling         bool     `json:"adaptive_polling" toml:"adaptive_polling" yaml:"adaptive_polling"`
+	ControlSocket           string   `json:"control_socket" toml:"control_socket" yaml:"control_socket"`
+	Romanize                bool     `json:"romanize" toml:"romanize" yaml:"romanize"`
+	ShowTranslation         bool     `json:"show_translation" toml:"show_translation" yaml:"show_translation"`
+	TranslationDir          string   `json:"translation_dir" toml:"translation_dir" yaml:"translation_dir"`
+	ClipboardTemplate       string   `json:"clipboard_template" toml:"clipboard_template" yaml:"clipboard_template"`
+	PreferredPlayers        []string `json:"preferred_players" toml:"preferred_players" yaml:"preferred_players"`
+	InstrumentalText        string   `json:"instrumental_text" toml:"instrumental_text" yaml:"instrumental_text"`
+	ClipboardDebounceMs     int      `json:"clipboard_debounce_ms" toml:"clipboard_debounce_ms" yaml:"clipboard_debounce_ms"`
+	UserAgent               string   `json:"user_agent" toml:"user_agent" yaml:"user_agent"`
+	CacheMaxEntries         int      `json:"cache_max_entries" toml:"cache_max_entries" yaml:"cache_max_entries"`
+	ClipboardMode           string   `json:"clipboard_mode" toml:"clipboard_mode" yaml:"clipboard_mode"`
+	ClipboardMaxLength      int      `json:"clipboard_max_length" toml:"clipboard_max_length" yaml:"clipboard_max_length"`
+	ClearOnTrackEnd         bool     `json:"clear_on_track_end" toml:"clear_on_track_end" yaml:"clear_on_track_end"`
+	TrackEndWindowMs        int      `json:"track_end_window_ms" toml:"track_end_window_ms" yaml:"track_end_window_ms"`
+	ClipboardBackend        string   `json:"clipboard_backend" toml:"clipboard_backend" yaml:"clipboard_backend"`
+	ClipboardTimeoutMs      int      `json:"clipboard_timeout_ms" toml:"clipboard_timeout_ms" yaml:"clipboard_timeout_ms"`
+	DiscordRPC              bool     `json:"discord_rpc" toml:"discord_rpc" yaml:"discord_rpc"`
+	DiscordClientID         string   `json:"discord_client_id" toml:"discord_client_id" yaml:"discord_client_id"`
+	IncludeTimestamp        bool     `json:"include_timestamp" toml:"include_timestamp" yaml:"include_timestamp"`
+	MaxDetectorFailures     int      `json:"max_detector_failures" toml:"max_detector_failures" yaml:"max_detector_failures"`
+	LeadTimeMs              int      `json:"lead_time_ms" toml:"lead_time_ms" yaml:"lead_time_ms"`
+	MinLineDisplayMs        int      `json:"min_line_display_ms" toml:"min_line_display_ms" yaml:"min_line_display_ms"`
+	NegativeCacheTTLMinutes int      `json:"negative_cache_ttl_minutes" toml:"negative_cache_ttl_minutes" yaml:"negative_cache_ttl_minutes"`
+	HTTPAddr                string   `json:"http_addr" toml:"http_addr" yaml:"http_addr"`
+	FetchTimeoutMs          int      `json:"fetch_timeout_ms" toml:"fetch_timeout_ms" yaml:"fetch_timeout_ms"`
+	IconTheme               string   `json:"icon_theme" toml:"icon_theme" yaml:"icon_theme"`
+	EnableHotkeys           bool     `json:"enable_hotkeys" toml:"enable_hotkeys" yaml:"enable_hotkeys"`
+	HotkeyOffsetBack        string   `json:"hotkey_offset_back" toml:"hotkey_offset_back" yaml:"hotkey_offset_back"`
+	HotkeyOffsetForward     string   `json:"hotkey_offset_forward" toml:"hotkey_offset_forward" yaml:"hotkey_offset_forward"`
+	HotkeyPause             string   `json:"hotkey_pause" toml:"hotkey_pause" yaml:"hotkey_pause"`
+	DemoLRC                 string   `json:"demo_lrc" toml:"demo_lrc" yaml:"demo_lrc"`
+	RadioMode               bool     `json:"radio_mode" toml:"radio_mode" yaml:"radio_mode"`
+	SpotifyToken            string   `json:"spotify_token" toml:"spotify_token" yaml:"spotify_token"`
+	SpotifyClientID         string   `json:"spotify_client_id" toml:"spotify_client_id" yaml:"spotify_client_id"`
+	SpotifyClientSecret     string   `json:"spotify_client_secret" toml:"spotify_client_secret" yaml:"spotify_client_secret"`
+	SpotifyRefreshToken     string   `json:"spotify_refresh_token" toml:"spotify_refresh_token" yaml:"spotify_refresh_token"`
+	OnLineCommand           string   `json:"on_line_command" toml:"on_line_command" yaml:"on_line_command"`
+	PlayerSelection         string   `json:"player_selection" toml:"player_selection" yaml:"player_selection"`
+	QuietHoursStart         string   `json:"quiet_hours_start" toml:"quiet_hours_start" yaml:"quiet_hours_start"`
+	QuietHoursEnd           string   `json:"quiet_hours_end" toml:"quiet_hours_end" yaml:"quiet_hours_end"`
+	StripAnnotations        bool     `json:"strip_annotations" toml:"strip_annotations" yaml:"strip_annotations"`
+	AnnotationPatterns      []string `json:"annotation_patterns" toml:"annotation_patterns" yaml:"annotation_patterns"`
+	LyricsRateLimit         float64  `json:"lyrics_rate_limit" toml:"lyrics_rate_limit" yaml:"lyrics_rate_limit"`
+	IgnoredPlayers          []string `json:"ignored_players" toml:"ignored_players" yaml:"ignored_players"`
+	ClipboardMinIntervalMs  int      `json:"clipboard_min_interval_ms" toml:"clipboard_min_interval_ms" yaml:"clipboard_min_interval_ms"`
+	StartupDelayMs          int      `json:"startup_delay_ms" toml:"startup_delay_ms" yaml:"startup_delay_ms"`
+	MaxLineLength           int      `json:"max_line_length" toml:"max_line_length" yaml:"max_line_length"`
+	ClearClipboardOnNoSong  bool     `json:"clear_clipboard_on_no_song" toml:"clear_clipboard_on_no_song" yaml:"clear_clipboard_on_no_song"`
+	NoSongText              string   `json:"no_song_text" toml:"no_song_text" yaml:"no_song_text"`
+	DetectDrift             bool     `json:"detect_drift" toml:"detect_drift" yaml:"detect_drift"`
+	AutoOffset              bool     `json:"auto_offset" toml:"auto_offset" yaml:"auto_offset"`
+	ClipboardHistoryLines   int      `json:"clipboard_history_lines" toml:"clipboard_history_lines" yaml:"clipboard_history_lines"`
+	PlayerctlFallback       bool     `json:"playerctl_fallback" toml:"playerctl_fallback" yaml:"playerctl_fallback"`
+	SkipLongTracksMinutes   int      `json:"skip_long_tracks_minutes" toml:"skip_long_tracks_minutes" yaml:"skip_long_tracks_minutes"`
+	FIFOPath                string   `json:"fifo_path" toml:"fifo_path" yaml:"fifo_path"`
+	ForceRewriteDuplicates  bool     `json:"force_rewrite_duplicates" toml:"force_rewrite_duplicates" yaml:"force_rewrite_duplicates"`
+	LocalDBPath             string   `json:"local_db_path" toml:"local_db_path" yaml:"local_db_path"`
+	CopyOnDemand            bool     `json:"copy_on_demand" toml:"copy_on_demand" yaml:"copy_on_demand"`
+	HotkeyCopyLine          string   `json:"hotkey_copy_line" toml:"hotkey_copy_line" yaml:"hotkey_copy_line"`
+	Debug                   bool     `json:"debug" toml:"debug" yaml:"debug"`
 }
 
 // Default returns a Config with sensible default values
 func Default() *Config {
 	return &Config{
-		PollInterval:      300 * time.Millisecond,
-		LyricOffset:       0,
-		EnableCache:       true,
-		UpdateClipboard:   true,
-		DemoMode:          false,
-		DemoArtist:        "Rick Astley",
-		DemoTitle:         "Never Gonna Give You Up",
-		StartMinimized:    false,
-		ShowNotifications: true,
+		PollInterval:         300 * time.Millisecond,
+		LyricOffset:          0,
+		EnableCache:          true,
+		UpdateClipboard:      true,
+		DemoMode:             false,
+		DemoArtist:           "Rick Astley",
+		DemoTitle:            "Never Gonna Give You Up",
+		StartMinimized:       false,
+		ShowNotifications:    true,
+		AdaptivePolling:      false,
+		ControlSocket:        "",
+		Romanize:             false,
+		ShowTranslation:      false,
+		TranslationDir:       "",
+		ClipboardTemplate:    "",
+		PreferredPlayers:     nil,
+		InstrumentalText:     "♪ (instrumental)",
+		ClipboardDebounce:    0,
+		UserAgent:            "",
+		CacheMaxEntries:      500,
+		ClipboardMode:        "replace",
+		ClipboardMaxLength:   4096,
+		ClearOnTrackEnd:      false,
+		TrackEndWindow:       1000 * time.Millisecond,
+		ClipboardBackend:     "",
+		ClipboardTimeout:     2 * time.Second,
+		DiscordRPC:           false,
+		DiscordClientID:      "",
+		IncludeTimestamp:     false,
+		MaxDetectorFailures:  3,
+		LeadTime:             0,
+		MinLineDisplay:       0,
+		NegativeCacheTTL:     time.Hour,
+		HTTPAddr:             "",
+		FetchTimeout:         20 * time.Second,
+		IconTheme:            "auto",
+		EnableHotkeys:        false,
+		HotkeyOffsetBack:     "ctrl+alt+left",
+		HotkeyOffsetForward:  "ctrl+alt+right",
+		HotkeyPause:          "ctrl+alt+p",
+		HotkeyCopyLine:       "ctrl+alt+c",
+		DemoLRC:              "",
+		RadioMode:            false,
+		SpotifyToken:         "",
+		SpotifyClientID:      "",
+		SpotifyClientSecret:  "",
+		SpotifyRefreshToken:  "",
+		OnLineCommand:        "",
+		PlayerSelection:      "first",
+		QuietHoursStart:      "",
+		QuietHoursEnd:        "",
+		StripAnnotations:     false,
+		AnnotationPatterns:   nil,
+		LyricsRateLimit:      2,
+		IgnoredPlayers:       nil,
+		ClipboardMinInterval: 0,
+		StartupDelay:         0,
 	}
 }
 
 // Load loads configuration from the specified file
-// If the file doesn't exist, returns default configuration
+// If the file doesn't exist, returns default configuration.
+// Environment variables are applied over the file values, so precedence is
+// defaults < config file < environment < command-line flags.
 func Load(path string) (*Config, error) {
+	config, err := loadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := config.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// loadFile loads configuration from the specified file without applying
+// environment overrides
+func loadFile(path string) (*Config, error) {
 	// If path is empty, use default location
-	if path == "" {
-		var err error
-		path, err = DefaultConfigPath()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get default config path: %w", err)
-		}
+	path, err := ResolvePath(path)
+	if err != nil {
+		return nil, err
 	}
 
 	// Check if file exists
@@ -82,29 +279,152 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	// Parse JSON
+	// Parse according to the file extension
 	var cf configFile
-	if err := json.Unmarshal(data, &cf); err != nil {
+	if err := unmarshal(formatFor(path), data, &cf); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
-	// Convert to Config
+	if cf.Version < CurrentVersion {
+		if cf, err = migrate(formatFor(path), data); err != nil {
+			return nil, fmt.Errorf("failed to migrate config file: %w", err)
+		}
+	}
+
+	return fromFile(cf), nil
+}
+
+// migrate upgrades a config file written by an older version. Settings added
+// since then are missing from the file and take their defaults, rather than
+// the zero value, so that e.g. enable_cache stays on for old files.
+func migrate(format string, data []byte) (configFile, error) {
+	// Decoding over the defaults only replaces the keys present in the file
+	cf := Default().toFile()
+	if err := unmarshal(format, data, &cf); err != nil {
+		return configFile{}, err
+	}
+	cf.Version = CurrentVersion
+	return cf, nil
+}
+
+// fromFile converts the on-disk representation into a Config
+func fromFile(cf configFile) *Config {
 	config := &Config{
-		PollInterval:      time.Duration(cf.PollIntervalMs) * time.Millisecond,
-		LyricOffset:       time.Duration(cf.LyricOffsetMs) * time.Millisecond,
-		EnableCache:       cf.EnableCache,
-		UpdateClipboard:   cf.UpdateClipboard,
-		DemoMode:          cf.DemoMode,
-		DemoArtist:        cf.DemoArtist,
-		DemoTitle:         cf.DemoTitle,
-		StartMinimized:    cf.StartMinimized,
-		ShowNotifications: cf.ShowNotifications,
+		PollInterval:           time.Duration(cf.PollIntervalMs) * time.Millisecond,
+		LyricOffset:            time.Duration(cf.LyricOffsetMs) * time.Millisecond,
+		EnableCache:            cf.EnableCache,
+		UpdateClipboard:        cf.UpdateClipboard,
+		DemoMode:               cf.DemoMode,
+		DemoArtist:             cf.DemoArtist,
+		DemoTitle:              cf.DemoTitle,
+		StartMinimized:         cf.StartMinimized,
+		ShowNotifications:      cf.ShowNotifications,
+		AdaptivePolling:        cf.AdaptivePolling,
+		ControlSocket:          cf.ControlSocket,
+		Romanize:               cf.Romanize,
+		ShowTranslation:        cf.ShowTranslation,
+		TranslationDir:         cf.TranslationDir,
+		ClipboardTemplate:      cf.ClipboardTemplate,
+		PreferredPlayers:       cf.PreferredPlayers,
+		InstrumentalText:       cf.InstrumentalText,
+		ClipboardDebounce:      time.Duration(cf.ClipboardDebounceMs) * time.Millisecond,
+		UserAgent:              cf.UserAgent,
+		CacheMaxEntries:        cf.CacheMaxEntries,
+		ClipboardMode:          cf.ClipboardMode,
+		ClipboardMaxLength:     cf.ClipboardMaxLength,
+		ClearOnTrackEnd:        cf.ClearOnTrackEnd,
+		TrackEndWindow:         time.Duration(cf.TrackEndWindowMs) * time.Millisecond,
+		ClipboardBackend:       cf.ClipboardBackend,
+		ClipboardTimeout:       time.Duration(cf.ClipboardTimeoutMs) * time.Millisecond,
+		DiscordRPC:             cf.DiscordRPC,
+		DiscordClientID:        cf.DiscordClientID,
+		IncludeTimestamp:       cf.IncludeTimestamp,
+		MaxDetectorFailures:    cf.MaxDetectorFailures,
+		LeadTime:               time.Duration(cf.LeadTimeMs) * time.Millisecond,
+		MinLineDisplay:         time.Duration(cf.MinLineDisplayMs) * time.Millisecond,
+		NegativeCacheTTL:       time.Duration(cf.NegativeCacheTTLMinutes) * time.Minute,
+		HTTPAddr:               cf.HTTPAddr,
+		FetchTimeout:           time.Duration(cf.FetchTimeoutMs) * time.Millisecond,
+		IconTheme:              cf.IconTheme,
+		EnableHotkeys:          cf.EnableHotkeys,
+		HotkeyOffsetBack:       cf.HotkeyOffsetBack,
+		HotkeyOffsetForward:    cf.HotkeyOffsetForward,
+		HotkeyPause:            cf.HotkeyPause,
+		DemoLRC:                cf.DemoLRC,
+		RadioMode:              cf.RadioMode,
+		SpotifyToken:           cf.SpotifyToken,
+		SpotifyClientID:        cf.SpotifyClientID,
+		SpotifyClientSecret:    cf.SpotifyClientSecret,
+		SpotifyRefreshToken:    cf.SpotifyRefreshToken,
+		OnLineCommand:          cf.OnLineCommand,
+		PlayerSelection:        cf.PlayerSelection,
+		QuietHoursStart:        cf.QuietHoursStart,
+		QuietHoursEnd:          cf.QuietHoursEnd,
+		StripAnnotations:       cf.StripAnnotations,
+		AnnotationPatterns:     cf.AnnotationPatterns,
+		LyricsRateLimit:        cf.LyricsRateLimit,
+		IgnoredPlayers:         cf.IgnoredPlayers,
+		ClipboardMinInterval:   time.Duration(cf.ClipboardMinIntervalMs) * time.Millisecond,
+		StartupDelay:           time.Duration(cf.StartupDelayMs) * time.Millisecond,
+		MaxLineLength:          cf.MaxLineLength,
+		ClearClipboardOnNoSong: cf.ClearClipboardOnNoSong,
+		NoSongText:             cf.NoSongText,
+		DetectDrift:            cf.DetectDrift,
+		AutoOffset:             cf.AutoOffset,
+		ClipboardHistoryLines:  cf.ClipboardHistoryLines,
+		PlayerctlFallback:      cf.PlayerctlFallback,
+		SkipLongTracksMinutes:  cf.SkipLongTracksMinutes,
+		FIFOPath:               cf.FIFOPath,
+		ForceRewriteDuplicates: cf.ForceRewriteDuplicates,
+		LocalDBPath:            cf.LocalDBPath,
+		CopyOnDemand:           cf.CopyOnDemand,
+		HotkeyCopyLine:         cf.HotkeyCopyLine,
+		Debug:                  cf.Debug,
 	}
 
 	// Apply defaults for zero values
 	if config.PollInterval == 0 {
 		config.PollInterval = 300 * time.Millisecond
 	}
+	if config.InstrumentalText == "" {
+		config.InstrumentalText = "♪ (instrumental)"
+	}
+	if config.CacheMaxEntries == 0 {
+		config.CacheMaxEntries = 500
+	}
+	if config.HotkeyOffsetBack == "" {
+		config.HotkeyOffsetBack = "ctrl+alt+left"
+	}
+	if config.HotkeyOffsetForward == "" {
+		config.HotkeyOffsetForward = "ctrl+alt+right"
+	}
+	if config.HotkeyPause == "" {
+		config.HotkeyPause = "ctrl+alt+p"
+	}
+	if config.HotkeyCopyLine == "" {
+		config.HotkeyCopyLine = "ctrl+alt+c"
+	}
+	if config.PlayerSelection == "" {
+		config.PlayerSelection = "first"
+	}
+	if config.IconTheme == "" {
+		config.IconTheme = "auto"
+	}
+	if config.ClipboardMode == "" {
+		config.ClipboardMode = "replace"
+	}
+	if config.MaxDetectorFailures == 0 {
+		config.MaxDetectorFailures = 3
+	}
+	if config.FetchTimeout == 0 {
+		config.FetchTimeout = 20 * time.Second
+	}
+	if config.LyricsRateLimit == 0 {
+		config.LyricsRateLimit = 2
+	}
+	if config.NegativeCacheTTL == 0 {
+		config.NegativeCacheTTL = time.Hour
+	}
 	if config.DemoArtist == "" {
 		config.DemoArtist = "Rick Astley"
 	}
@@ -112,18 +432,92 @@ func Load(path string) (*Config, error) {
 		config.DemoTitle = "Never Gonna Give You Up"
 	}
 
-	return config, nil
+	return config
+}
+
+// toFile converts a Config into its on-disk representation
+func (c *Config) toFile() configFile {
+	return configFile{
+		Version:                 CurrentVersion,
+		PollIntervalMs:          int(c.PollInterval.Milliseconds()),
+		LyricOffsetMs:           int(c.LyricOffset.Milliseconds()),
+		EnableCache:             c.EnableCache,
+		UpdateClipboard:         c.UpdateClipboard,
+		DemoMode:                c.DemoMode,
+		DemoArtist:              c.DemoArtist,
+		DemoTitle:               c.DemoTitle,
+		StartMinimized:          c.StartMinimized,
+		ShowNotifications:       c.ShowNotifications,
+		AdaptivePolling:         c.AdaptivePolling,
+		ControlSocket:           c.ControlSocket,
+		Romanize:                c.Romanize,
+		ShowTranslation:         c.ShowTranslation,
+		TranslationDir:          c.TranslationDir,
+		ClipboardTemplate:       c.ClipboardTemplate,
+		PreferredPlayers:        c.PreferredPlayers,
+		InstrumentalText:        c.InstrumentalText,
+		ClipboardDebounceMs:     int(c.ClipboardDebounce.Milliseconds()),
+		UserAgent:               c.UserAgent,
+		CacheMaxEntries:         c.CacheMaxEntries,
+		ClipboardMode:           c.ClipboardMode,
+		ClipboardMaxLength:      c.ClipboardMaxLength,
+		ClearOnTrackEnd:         c.ClearOnTrackEnd,
+		TrackEndWindowMs:        int(c.TrackEndWindow.Milliseconds()),
+		ClipboardBackend:        c.ClipboardBackend,
+		ClipboardTimeoutMs:      int(c.ClipboardTimeout.Milliseconds()),
+		DiscordRPC:              c.DiscordRPC,
+		DiscordClientID:         c.DiscordClientID,
+		IncludeTimestamp:        c.IncludeTimestamp,
+		MaxDetectorFailures:     c.MaxDetectorFailures,
+		LeadTimeMs:              int(c.LeadTime.Milliseconds()),
+		MinLineDisplayMs:        int(c.MinLineDisplay.Milliseconds()),
+		NegativeCacheTTLMinutes: int(c.NegativeCacheTTL.Minutes()),
+		HTTPAddr:                c.HTTPAddr,
+		FetchTimeoutMs:          int(c.FetchTimeout.Milliseconds()),
+		IconTheme:               c.IconTheme,
+		EnableHotkeys:           c.EnableHotkeys,
+		HotkeyOffsetBack:        c.HotkeyOffsetBack,
+		HotkeyOffsetForward:     c.HotkeyOffsetForward,
+		HotkeyPause:             c.HotkeyPause,
+		DemoLRC:                 c.DemoLRC,
+		RadioMode:               c.RadioMode,
+		SpotifyToken:            c.SpotifyToken,
+		SpotifyClientID:         c.SpotifyClientID,
+		SpotifyClientSecret:     c.SpotifyClientSecret,
+		SpotifyRefreshToken:     c.SpotifyRefreshToken,
+		OnLineCommand:           c.OnLineCommand,
+		PlayerSelection:         c.PlayerSelection,
+		QuietHoursStart:         c.QuietHoursStart,
+		QuietHoursEnd:           c.QuietHoursEnd,
+		StripAnnotations:        c.StripAnnotations,
+		AnnotationPatterns:      c.AnnotationPatterns,
+		LyricsRateLimit:         c.LyricsRateLimit,
+		IgnoredPlayers:          c.IgnoredPlayers,
+		ClipboardMinIntervalMs:  int(c.ClipboardMinInterval.Milliseconds()),
+		StartupDelayMs:          int(c.StartupDelay.Milliseconds()),
+		MaxLineLength:           c.MaxLineLength,
+		ClearClipboardOnNoSong:  c.ClearClipboardOnNoSong,
+		NoSongText:              c.NoSongText,
+		DetectDrift:             c.DetectDrift,
+		AutoOffset:              c.AutoOffset,
+		ClipboardHistoryLines:   c.ClipboardHistoryLines,
+		PlayerctlFallback:       c.PlayerctlFallback,
+		SkipLongTracksMinutes:   c.SkipLongTracksMinutes,
+		FIFOPath:                c.FIFOPath,
+		ForceRewriteDuplicates:  c.ForceRewriteDuplicates,
+		LocalDBPath:             c.LocalDBPath,
+		CopyOnDemand:            c.CopyOnDemand,
+		HotkeyCopyLine:          c.HotkeyCopyLine,
+		Debug:                   c.Debug,
+	}
 }
 
 // Save saves the configuration to the specified file
 func (c *Config) Save(path string) error {
 	// If path is empty, use default location
-	if path == "" {
-		var err error
-		path, err = DefaultConfigPath()
-		if err != nil {
-			return fmt.Errorf("failed to get default config path: %w", err)
-		}
+	path, err := ResolvePath(path)
+	if err != nil {
+		return err
 	}
 
 	// Ensure directory exists
@@ -132,21 +526,8 @@ func (c *Config) Save(path string) error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	// Convert to configFile
-	cf := configFile{
-		PollIntervalMs:    int(c.PollInterval.Milliseconds()),
-		LyricOffsetMs:     int(c.LyricOffset.Milliseconds()),
-		EnableCache:       c.EnableCache,
-		UpdateClipboard:   c.UpdateClipboard,
-		DemoMode:          c.DemoMode,
-		DemoArtist:        c.DemoArtist,
-		DemoTitle:         c.DemoTitle,
-		StartMinimized:    c.StartMinimized,
-		ShowNotifications: c.ShowNotifications,
-	}
-
-	// Marshal to JSON with indentation
-	data, err := json.MarshalIndent(cf, "", "  ")
+	// Marshal in the format matching the file extension
+	data, err := marshal(formatFor(path), c.toFile())
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -159,6 +540,33 @@ func (c *Config) Save(path string) error {
 	return nil
 }
 
+// Update applies change to the settings stored at path, or the default
+// location if path is empty, and saves them. The file is read again rather
+// than saving the running config, so environment variables and flags don't
+// end up in it.
+func Update(path string, change func(*Config)) error {
+	config, err := loadFile(path)
+	if err != nil {
+		return err
+	}
+
+	change(config)
+	return config.Save(path)
+}
+
+// ResolvePath returns path, or the default configuration path if path is empty
+func ResolvePath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+
+	path, err := DefaultConfigPath()
+	if err != nil {
+		return "", fmt.Errorf("failed to get default config path: %w", err)
+	}
+	return path, nil
+}
+
 // DefaultConfigPath returns the default configuration file path
 func DefaultConfigPath() (string, error) {
 	// Get user config directory
@@ -172,9 +580,10 @@ func DefaultConfigPath() (string, error) {
 	return filepath.Join(appConfigDir, "config.json"), nil
 }
 
-// GenerateExample generates an example configuration file at the default location
-func GenerateExample() error {
-	path, err := DefaultConfigPath()
+// GenerateExample generates an example configuration file at path, or at the
+// default location if path is empty. The format follows the file extension.
+func GenerateExample(path string) error {
+	path, err := ResolvePath(path)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables that override config file values
+const (
+	envLyricOffset      = "LYRIC_OFFSET_MS"
+	envPollInterval     = "LYRIC_POLL_MS"
+	envUpdateClipboard  = "LYRIC_UPDATE_CLIPBOARD"
+	envPreferredPlayers = "LYRIC_PREFERRED_PLAYERS"
+)
+
+// applyEnv overrides configuration values with any that are set in the
+// environment
+func (c *Config) applyEnv() error {
+	if value, ok := os.LookupEnv(envLyricOffset); ok {
+		ms, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", envLyricOffset, err)
+		}
+		c.LyricOffset = time.Duration(ms) * time.Millisecond
+	}
+
+	if value, ok := os.LookupEnv(envPollInterval); ok {
+		ms, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", envPollInterval, err)
+		}
+		interval := time.Duration(ms) * time.Millisecond
+		// Load doesn't validate, and too short an interval busy-loops
+		if interval < minPollInterval || interval > maxPollInterval {
+			return fmt.Errorf("invalid %s: must be between %d and %d, got %d", envPollInterval,
+				minPollInterval.Milliseconds(), maxPollInterval.Milliseconds(), ms)
+		}
+		c.PollInterval = interval
+	}
+
+	if value, ok := os.LookupEnv(envUpdateClipboard); ok {
+		enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", envUpdateClipboard, err)
+		}
+		c.UpdateClipboard = enabled
+	}
+
+	if value, ok := os.LookupEnv(envPreferredPlayers); ok {
+		c.PreferredPlayers = nil
+		for _, player := range strings.Split(value, ",") {
+			if player = strings.TrimSpace(player); player != "" {
+				c.PreferredPlayers = append(c.PreferredPlayers, player)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported config file formats
+const (
+	formatJSON = "json"
+	formatTOML = "toml"
+	formatYAML = "yaml"
+)
+
+// formatFor picks the file format from the path's extension, falling back
+// to JSON for unknown extensions
+func formatFor(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		return formatTOML
+	case ".yaml", ".yml":
+		return formatYAML
+	default:
+		return formatJSON
+	}
+}
+
+// unmarshal decodes data in the given format into v
+func unmarshal(format string, data []byte, v interface{}) error {
+	switch format {
+	case formatTOML:
+		return toml.Unmarshal(data, v)
+	case formatYAML:
+		return yaml.Unmarshal(data, v)
+	default:
+		return json.Unmarshal(data, v)
+	}
+}
+
+// marshal encodes v in the given format
+func marshal(format string, v interface{}) ([]byte, error) {
+	switch format {
+	case formatTOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(v); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case formatYAML:
+		return yaml.Marshal(v)
+	default:
+		return json.MarshalIndent(v, "", "  ")
+	}
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Limits used when validating configuration values
+const (
+	minPollInterval         = 10 * time.Millisecond
+	maxPollInterval         = 10 * time.Second
+	maxLyricOffset          = 30 * time.Second
+	maxLeadTime             = 5 * time.Second
+	maxClipboardDebounce    = 2 * time.Second
+	maxClipboardMinInterval = time.Minute
+	maxFetchTimeout         = 2 * time.Minute
+	maxStartupDelay         = 5 * time.Minute
+)
+
+// Validate checks the configuration values and returns a description of
+// each problem found
+func (c *Config) Validate() []string {
+	var problems []string
+
+	if c.PollInterval < minPollInterval || c.PollInterval > maxPollInterval {
+		problems = append(problems, fmt.Sprintf("poll_interval_ms must be between %d and %d, got %d",
+			minPollInterval.Milliseconds(), maxPollInterval.Milliseconds(), c.PollInterval.Milliseconds()))
+	}
+	if c.LyricOffset < -maxLyricOffset || c.LyricOffset > maxLyricOffset {
+		problems = append(problems, fmt.Sprintf("lyric_offset_ms must be between %d and %d, got %d",
+			-maxLyricOffset.Milliseconds(), maxLyricOffset.Milliseconds(), c.LyricOffset.Milliseconds()))
+	}
+
+	if c.LeadTime < 0 || c.LeadTime > maxLeadTime {
+		problems = append(problems, fmt.Sprintf("lead_time_ms must be between 0 and %d, got %d",
+			maxLeadTime.Milliseconds(), c.LeadTime.Milliseconds()))
+	}
+
+	if c.ClipboardMode != "replace" && c.ClipboardMode != "append" {
+		problems = append(problems, fmt.Sprintf("clipboard_mode must be \"replace\" or \"append\", got %q", c.ClipboardMode))
+	}
+	if c.PlayerSelection != "first" && c.PlayerSelection != "recent" && c.PlayerSelection != "preferred" {
+		problems = append(problems, fmt.Sprintf("player_selection must be \"first\", \"recent\" or \"preferred\", got %q", c.PlayerSelection))
+	}
+	if c.IconTheme != "auto" && c.IconTheme != "light" && c.IconTheme != "dark" {
+		problems = append(problems, fmt.Sprintf("icon_theme must be \"auto\", \"light\" or \"dark\", got %q", c.IconTheme))
+	}
+	if (c.QuietHoursStart == "") != (c.QuietHoursEnd == "") {
+		problems = append(problems, "quiet_hours_start and quiet_hours_end must be set together")
+	}
+	for _, field := range []struct{ name, value string }{
+		{"quiet_hours_start", c.QuietHoursStart},
+		{"quiet_hours_end", c.QuietHoursEnd},
+	} {
+		if _, err := time.Parse("15:04", field.value); field.value != "" && err != nil {
+			problems = append(problems, fmt.Sprintf("%s must be a time of day like \"09:00\", got %q", field.name, field.value))
+		}
+	}
+	for _, pattern := range c.AnnotationPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			problems = append(problems, fmt.Sprintf("annotation_patterns entry %q is not a valid regular expression: %v", pattern, err))
+		}
+	}
+	if c.ClipboardHistoryLines < 0 {
+		problems = append(problems, fmt.Sprintf("clipboard_history_lines must not be negative, got %d", c.ClipboardHistoryLines))
+	}
+	if c.ClipboardHistoryLines > 1 && c.ClipboardMode == "append" {
+		problems = append(problems, "clipboard_history_lines can't be combined with clipboard_mode \"append\"")
+	}
+	if c.SkipLongTracksMinutes < 0 {
+		problems = append(problems, fmt.Sprintf("skip_long_tracks_minutes must not be negative, got %d", c.SkipLongTracksMinutes))
+	}
+	if c.MaxLineLength < 0 {
+		problems = append(problems, fmt.Sprintf("max_line_length must not be negative, got %d", c.MaxLineLength))
+	}
+	if c.ClipboardMaxLength < 0 {
+		problems = append(problems, fmt.Sprintf("clipboard_max_length must not be negative, got %d", c.ClipboardMaxLength))
+	}
+	if c.DiscordRPC && c.DiscordClientID == "" {
+		problems = append(problems, "discord_client_id is required when discord_rpc is enabled")
+	}
+	if c.SpotifyRefreshToken != "" && c.SpotifyClientID == "" {
+		problems = append(problems, "spotify_client_id is required when spotify_refresh_token is set")
+	}
+	if c.ClipboardDebounce < 0 || c.ClipboardDebounce > maxClipboardDebounce {
+		problems = append(problems, fmt.Sprintf("clipboard_debounce_ms must be between 0 and %d, got %d",
+			maxClipboardDebounce.Milliseconds(), c.ClipboardDebounce.Milliseconds()))
+	}
+	if c.ClipboardMinInterval < 0 || c.ClipboardMinInterval > maxClipboardMinInterval {
+		problems = append(problems, fmt.Sprintf("clipboard_min_interval_ms must be between 0 and %d, got %d",
+			maxClipboardMinInterval.Milliseconds(), c.ClipboardMinInterval.Milliseconds()))
+	}
+	if c.StartupDelay < 0 || c.StartupDelay > maxStartupDelay {
+		problems = append(problems, fmt.Sprintf("startup_delay_ms must be between 0 and %d, got %d",
+			maxStartupDelay.Milliseconds(), c.StartupDelay.Milliseconds()))
+	}
+	if c.FetchTimeout < 0 || c.FetchTimeout > maxFetchTimeout {
+		problems = append(problems, fmt.Sprintf("fetch_timeout_ms must be between 0 and %d, got %d",
+			maxFetchTimeout.Milliseconds(), c.FetchTimeout.Milliseconds()))
+	}
+
+	return problems
+}
+
+// CheckFile loads the configuration file at path and reports unknown keys
+// and invalid values. The resolved configuration is returned along with the
+// problems found. A missing file is not a problem; defaults are used.
+func CheckFile(path string) (*Config, []string, error) {
+	path, err := ResolvePath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config, err := Load(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var problems []string
+
+	// Look for keys that don't correspond to any setting
+	data, err := os.ReadFile(path)
+	if err == nil {
+		var raw map[string]interface{}
+		if err := unmarshal(formatFor(path), data, &raw); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+
+		known := knownKeys()
+		var unknown []string
+		for key := range raw {
+			if !known[key] {
+				unknown = append(unknown, key)
+			}
+		}
+		sort.Strings(unknown)
+
+		for _, key := range unknown {
+			problems = append(problems, fmt.Sprintf("unknown key %q is ignored", key))
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	problems = append(problems, config.Validate()...)
+	return config, problems, nil
+}
+
+// knownKeys returns the set of keys understood in the config file
+func knownKeys() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(configFile{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+// JSON returns the configuration in its config file representation
+func (c *Config) JSON() ([]byte, error) {
+	return json.MarshalIndent(c.toFile(), "", "  ")
+}
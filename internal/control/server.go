@@ -0,0 +1,304 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/orchestrator"
+)
+
+// eventBufferSize is how many events a slow subscriber may fall behind
+// before further events are dropped for it
+const eventBufferSize = 16
+
+// Request is a single command sent by a client, one JSON object per line
+type Request struct {
+	ID     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is the reply to a Request
+type Response struct {
+	ID     int         `json:"id"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// EventMessage is pushed to subscribed clients on every song, line and state
+// change
+type EventMessage struct {
+	Event  string `json:"event"`
+	Artist string `json:"artist"`
+	Title  string `json:"title"`
+	Album  string `json:"album,omitempty"`
+	Line   string `json:"line,omitempty"`
+	State  string `json:"state,omitempty"`
+}
+
+// Status is the result of the "status" method
+type Status struct {
+	Status    string `json:"status"`
+	Song      string `json:"song"`
+	Line      string `json:"line"`
+	OffsetMs  int64  `json:"offset_ms"`
+	Clipboard bool   `json:"clipboard"`
+	Paused    bool   `json:"paused"`
+}
+
+// Server exposes the orchestrator's controls over a Unix socket.
+//
+// Clients send newline-delimited JSON requests:
+//
+//	{"id": 1, "method": "status"}
+//	{"id": 2, "method": "set_offset", "params": {"offset_ms": 500}}
+//	{"id": 3, "method": "set_clipboard", "params": {"enabled": false}}
+//	{"id": 4, "method": "toggle_clipboard"}
+//	{"id": 5, "method": "pause"}
+//	{"id": 6, "method": "resume"}
+//	{"id": 7, "method": "next_line"}
+//	{"id": 8, "method": "prev_line"}
+//	{"id": 9, "method": "cache_stats"}
+//	{"id": 10, "method": "copy_line"}
+//	{"id": 11, "method": "subscribe"}
+//
+// After "subscribe", the connection receives an EventMessage line for every
+// song, line and state change until the client disconnects.
+type Server struct {
+	orchestrator *orchestrator.Orchestrator
+	path         string
+	listener     net.Listener
+	subscribers  map[chan orchestrator.Event]struct{}
+	mu           sync.Mutex
+}
+
+// NewServer creates a control server listening on the given socket path
+func NewServer(orch *orchestrator.Orchestrator, path string) *Server {
+	s := &Server{
+		orchestrator: orch,
+		path:         path,
+		subscribers:  make(map[chan orchestrator.Event]struct{}),
+	}
+
+	// Fan out orchestrator events to subscribed clients
+	orch.AddEventHandler(s.broadcast)
+
+	return s
+}
+
+// Start begins accepting connections in the background
+func (s *Server) Start() error {
+	// Remove a stale socket left behind by a previous run
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", s.path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.path, err)
+	}
+	s.listener = listener
+
+	log.Printf("Control API listening on %s", s.path)
+	go s.acceptLoop()
+	return nil
+}
+
+// Close stops the server and removes the socket file
+func (s *Server) Close() error {
+	if s.listener == nil {
+		return nil
+	}
+	err := s.listener.Close()
+	os.Remove(s.path)
+	return err
+}
+
+// acceptLoop accepts client connections until the listener is closed
+func (s *Server) acceptLoop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handleConn(conn)
+	}
+}
+
+// handleConn serves requests from a single client
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	encoder := json.NewEncoder(conn)
+
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			encoder.Encode(Response{Error: fmt.Sprintf("invalid request: %v", err)})
+			continue
+		}
+
+		if req.Method == "subscribe" {
+			encoder.Encode(Response{ID: req.ID, Result: "subscribed"})
+			s.stream(conn, encoder)
+			return
+		}
+
+		result, err := s.call(req)
+		resp := Response{ID: req.ID, Result: result}
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// call dispatches a request to the orchestrator
+func (s *Server) call(req Request) (interface{}, error) {
+	switch req.Method {
+	case "status":
+		return Status{
+			Status:    s.orchestrator.GetCurrentStatus(),
+			Song:      s.orchestrator.GetCurrentSongKey(),
+			Line:      s.orchestrator.GetCurrentLine(),
+			OffsetMs:  s.orchestrator.GetLyricOffset().Milliseconds(),
+			Clipboard: s.orchestrator.GetUpdateClipboard(),
+			Paused:    s.orchestrator.IsPaused(),
+		}, nil
+
+	case "set_offset":
+		var params struct {
+			OffsetMs *int64 `json:"offset_ms"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.OffsetMs == nil {
+			return nil, fmt.Errorf("set_offset requires offset_ms")
+		}
+		s.orchestrator.SetLyricOffset(time.Duration(*params.OffsetMs) * time.Millisecond)
+		return "ok", nil
+
+	case "set_clipboard":
+		var params struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Enabled == nil {
+			return nil, fmt.Errorf("set_clipboard requires enabled")
+		}
+		s.orchestrator.SetUpdateClipboard(*params.Enabled)
+		return "ok", nil
+
+	case "toggle_clipboard":
+		enabled := !s.orchestrator.GetUpdateClipboard()
+		s.orchestrator.SetUpdateClipboard(enabled)
+		return enabled, nil
+
+	case "pause":
+		s.orchestrator.Pause()
+		return "ok", nil
+
+	case "resume":
+		s.orchestrator.Resume()
+		return "ok", nil
+
+	case "cache_stats":
+		stats, ok := s.orchestrator.GetCacheStats()
+		if !ok {
+			return nil, fmt.Errorf("lyrics provider has no cache")
+		}
+		return stats, nil
+
+	case "next_line":
+		if err := s.orchestrator.NextLine(); err != nil {
+			return nil, err
+		}
+		return "ok", nil
+
+	case "prev_line":
+		if err := s.orchestrator.PrevLine(); err != nil {
+			return nil, err
+		}
+		return "ok", nil
+
+	case "copy_line":
+		if err := s.orchestrator.CopyCurrentLine(); err != nil {
+			return nil, err
+		}
+		return "ok", nil
+
+	default:
+		return nil, fmt.Errorf("unknown method %q", req.Method)
+	}
+}
+
+// stream writes events to a subscribed client until it disconnects
+func (s *Server) stream(conn net.Conn, encoder *json.Encoder) {
+	events := make(chan orchestrator.Event, eventBufferSize)
+	s.mu.Lock()
+	s.subscribers[events] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, events)
+		s.mu.Unlock()
+	}()
+
+	// Detect disconnects, since subscribed clients send nothing further
+	closed := make(chan struct{})
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if _, err := conn.Read(buf); err != nil {
+				close(closed)
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case event := <-events:
+			// Only state events carry a state
+			var state string
+			if event.Type == orchestrator.EventStateChange {
+				state = event.State.String()
+			}
+
+			msg := EventMessage{
+				Event:  event.Type.String(),
+				Artist: event.Song.Artist,
+				Title:  event.Song.Title,
+				Album:  event.Song.Album,
+				Line:   event.Line,
+				State:  state,
+			}
+			if err := encoder.Encode(msg); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// broadcast delivers an event to all subscribers without blocking
+func (s *Server) broadcast(event orchestrator.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for events := range s.subscribers {
+		select {
+		case events <- event:
+		default:
+			// Subscriber is too slow, drop the event
+		}
+	}
+}
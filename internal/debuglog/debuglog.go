@@ -0,0 +1,25 @@
+// Package debuglog logs detail that is only useful when tracking down a
+// problem, such as ignored player readings or cache revalidations. It is
+// off unless the debug setting is enabled.
+package debuglog
+
+import (
+	"log"
+	"sync/atomic"
+)
+
+// enabled is set from the debug setting at startup
+var enabled atomic.Bool
+
+// SetEnabled turns debug logging on or off
+func SetEnabled(on bool) {
+	enabled.Store(on)
+}
+
+// Printf logs a message, prefixed with "DEBUG: ", if debug logging is on.
+// Arguments are handled like log.Printf.
+func Printf(format string, v ...any) {
+	if enabled.Load() {
+		log.Printf("DEBUG: "+format, v...)
+	}
+}
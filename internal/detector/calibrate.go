@@ -0,0 +1,95 @@
+package detector
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxCalibrationJump is how far a track's implied start time may move between
+// samples before it is treated as a seek rather than reporting lag
+const maxCalibrationJump = 2 * time.Second
+
+// Calibration is the result of measuring a detector's position reporting
+type Calibration struct {
+	Samples int
+
+	// AverageStaleness is how far the reported position lags behind the
+	// freshest report seen, on average
+	AverageStaleness time.Duration
+
+	// MaxStaleness is the largest lag seen in any sample
+	MaxStaleness time.Duration
+}
+
+// Calibrate polls the detector samples times, interval apart, while a track
+// plays, and measures how stale the reported positions are.
+//
+// Each sample implies a track start time (the wall clock minus the reported
+// position). A player that reports fresh positions gives the same start time
+// every poll; a stale report gives a later one. Lag that every sample shares
+// can't be seen this way, so the result is a lower bound.
+func Calibrate(det Detector, samples int, interval time.Duration) (*Calibration, error) {
+	if samples < 2 {
+		return nil, fmt.Errorf("calibration needs at least 2 samples")
+	}
+
+	var starts []time.Time
+	var trackKey string
+
+	for len(starts) < samples {
+		if len(starts) > 0 {
+			time.Sleep(interval)
+		}
+
+		before := time.Now()
+		song, err := det.GetCurrentSong()
+		if err != nil {
+			return nil, err
+		}
+		// The position was read at some point during the call
+		readAt := before.Add(time.Since(before) / 2)
+
+		if !song.IsPlaying {
+			return nil, fmt.Errorf("playback is paused, keep the track playing while calibrating")
+		}
+		if song.PositionEstimated {
+			return nil, fmt.Errorf("the player doesn't report a position, so there is nothing to calibrate")
+		}
+
+		key := song.TrackID + "|" + song.Artist + " - " + song.Title
+		if trackKey != "" && key != trackKey {
+			return nil, fmt.Errorf("the track changed during calibration")
+		}
+		trackKey = key
+
+		start := readAt.Add(-song.Position)
+		if len(starts) > 0 {
+			jump := start.Sub(starts[len(starts)-1])
+			if jump > maxCalibrationJump || jump < -maxCalibrationJump {
+				return nil, fmt.Errorf("the playback position jumped, avoid seeking while calibrating")
+			}
+		}
+		starts = append(starts, start)
+	}
+
+	// The earliest implied start comes from the freshest report
+	freshest := starts[0]
+	for _, start := range starts[1:] {
+		if start.Before(freshest) {
+			freshest = start
+		}
+	}
+
+	result := &Calibration{Samples: len(starts)}
+	var total time.Duration
+	for _, start := range starts {
+		staleness := start.Sub(freshest)
+		total += staleness
+		if staleness > result.MaxStaleness {
+			result.MaxStaleness = staleness
+		}
+	}
+	result.AverageStaleness = total / time.Duration(len(starts))
+
+	return result, nil
+}
@@ -1,6 +1,15 @@
 package detector
 
-import "time"
+import (
+	"errors"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrNoSong is returned by detectors when nothing is currently playing.
+// Any other error means detection itself failed.
+var ErrNoSong = errors.New("no song playing")
 
 // SongInfo represents the currently playing song and its state
 type SongInfo struct {
@@ -8,15 +17,114 @@ type SongInfo struct {
 	Title     string
 	Album     string
 	Position  time.Duration // Current playback position
+	Duration  time.Duration // Track length, zero if unknown
+	TrackID   string        // Player's identifier for the track, empty if not reported
+	ArtURL    string        // Cover art as a file:// or http(s) URL, empty if not reported
+	Source    string        // Player that reported the song: its MPRIS bus name on Linux or app id on Windows, empty if unknown
 	IsPlaying bool
+
+	// PositionEstimated is set when the player doesn't report a position and
+	// Position was derived from the time elapsed since the track started
+	PositionEstimated bool
 }
 
 // Detector is the interface for platform-specific song detection
 type Detector interface {
 	// GetCurrentSong returns the currently playing song information
-	// Returns nil if no song is playing or if detection fails
+	// Returns ErrNoSong if no song is playing, or another error if detection fails
 	GetCurrentSong() (*SongInfo, error)
 
 	// Close cleans up any resources used by the detector
 	Close() error
 }
+
+// Options configures the platform detector
+type Options struct {
+	// PreferredPlayers are checked before the built-in player list.
+	// On Linux these are MPRIS names like "spotify" or full bus names.
+	PreferredPlayers []string
+
+	// PlayerSelection picks among several playing players, one of the
+	// Select constants. Empty means SelectFirst.
+	PlayerSelection string
+
+	// SplitStreamTitles accepts tracks without an artist whose title has the
+	// "Artist - Title" form that internet radio streams send, splitting it
+	// into artist and title
+	SplitStreamTitles bool
+
+	// IgnoredPlayers are never followed, e.g. a video player that grabs the
+	// media session. See IsIgnoredPlayer for how they are matched.
+	IgnoredPlayers []string
+
+	// PlayerctlFallback asks playerctl for the song when no player is found
+	// over D-Bus, if playerctl is installed. Linux only.
+	PlayerctlFallback bool
+}
+
+// Player selection policies, for when several players are playing at once
+const (
+	// SelectFirst uses the first playing player among the preferred and
+	// built-in players, in that order
+	SelectFirst = "first"
+	// SelectRecent uses the playing player whose track changed most recently
+	SelectRecent = "recent"
+	// SelectPreferred considers every player on the system, ranking the
+	// preferred players first
+	SelectPreferred = "preferred"
+)
+
+// HealthChecker is implemented by detectors that can report whether their
+// underlying connection to the media system is usable
+type HealthChecker interface {
+	Healthy() bool
+}
+
+// PlayerInfo describes a media player visible to the platform's media system
+type PlayerInfo struct {
+	Name   string // D-Bus service name or application id
+	Status string // Playback status as reported by the player
+	Artist string
+	Title  string
+}
+
+// IsIgnoredPlayer reports whether a player's D-Bus name or application id
+// matches one of the patterns. Patterns containing *, ? or [ are globs
+// matched against the whole name and against the MPRIS short name, e.g.
+// "chromium.*" for "org.mpris.MediaPlayer2.chromium.instance42"; other
+// patterns match any part of the name. Case is ignored.
+func IsIgnoredPlayer(name string, patterns []string) bool {
+	name = strings.ToLower(name)
+	short := strings.TrimPrefix(name, "org.mpris.mediaplayer2.")
+
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if !strings.ContainsAny(pattern, "*?[") {
+			if strings.Contains(name, pattern) {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, short); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitStreamTitle splits a radio stream title of the form "Artist - Title".
+// Returns false if the title doesn't have that form.
+func SplitStreamTitle(streamTitle string) (artist, title string, ok bool) {
+	artist, title, ok = strings.Cut(streamTitle, " - ")
+	artist, title = strings.TrimSpace(artist), strings.TrimSpace(title)
+	if !ok || artist == "" || title == "" {
+		return "", "", false
+	}
+	return artist, title, true
+}
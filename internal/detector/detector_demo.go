@@ -1,42 +1,157 @@
 package detector
 
 import (
-	"fmt"
+	"sort"
 	"time"
+
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/clock"
 )
 
+// demoDuration is the reported track length, that of the default demo song
+const demoDuration = 3*time.Minute + 33*time.Second
+
 // DemoDetector simulates a playing song for testing purposes
 type DemoDetector struct {
+	clock     clock.Clock
 	startTime time.Time
 	artist    string
 	title     string
+	duration  time.Duration
+	loop      bool
 }
 
 // NewDemoDetector creates a detector that simulates a playing song
 func NewDemoDetector(artist, title string) Detector {
 	return &DemoDetector{
+		clock:     clock.Real,
+		startTime: time.Now(),
+		artist:    artist,
+		title:     title,
+		duration:  demoDuration,
+	}
+}
+
+// NewLoopingDemoDetector creates a detector that simulates a song of the
+// given length playing on repeat
+func NewLoopingDemoDetector(artist, title string, duration time.Duration) Detector {
+	return &DemoDetector{
+		clock:     clock.Real,
 		startTime: time.Now(),
 		artist:    artist,
 		title:     title,
+		duration:  duration,
+		loop:      duration > 0,
 	}
 }
 
+// SetClock makes the detector tell the position by c, restarting the song
+// at c's current time
+func (d *DemoDetector) SetClock(c clock.Clock) {
+	d.clock = c
+	d.startTime = c.Now()
+}
+
 // GetCurrentSong returns simulated song information
 func (d *DemoDetector) GetCurrentSong() (*SongInfo, error) {
 	// Calculate elapsed time since start
-	elapsed := time.Since(d.startTime)
+	elapsed := d.clock.Now().Sub(d.startTime)
+	if d.loop {
+		elapsed %= d.duration
+	}
 
 	return &SongInfo{
 		Artist:    d.artist,
 		Title:     d.title,
 		Album:     "Demo Album",
 		Position:  elapsed,
+		Duration:  d.duration,
 		IsPlaying: true,
 	}, nil
 }
 
 // Close is a no-op for demo detector
 func (d *DemoDetector) Close() error {
-	fmt.Println("Demo detector closed")
+	return nil
+}
+
+// DemoEvent is a change in playback for a ScriptedDetector
+type DemoEvent struct {
+	At       time.Duration // When the event happens, measured from when the detector was created
+	Artist   string        // Track from this event on; empty keeps the previous event's track
+	Title    string
+	Duration time.Duration // Track length, zero if unknown or to keep the previous one
+	Position time.Duration // Playback position at At
+	Playing  bool          // Whether the position advances after At
+	Stopped  bool          // Nothing plays from At on, the other fields are ignored
+}
+
+// ScriptedDetector plays back a fixed sequence of events, so tests can
+// simulate pausing, resuming, seeking and changing tracks at known times.
+// Between events the position advances with the clock while playing.
+type ScriptedDetector struct {
+	clock     clock.Clock
+	startTime time.Time
+	events    []DemoEvent // Sorted by At, with the track filled in
+}
+
+// NewScriptedDetector creates a detector that follows events, which may be
+// given in any order. Before the first event nothing is playing.
+func NewScriptedDetector(events []DemoEvent) *ScriptedDetector {
+	sorted := append([]DemoEvent{}, events...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].At < sorted[j].At })
+
+	// Carry the track forward so each event is complete
+	for i := 1; i < len(sorted); i++ {
+		prev := sorted[i-1]
+		if sorted[i].Artist == "" && sorted[i].Title == "" {
+			sorted[i].Artist, sorted[i].Title = prev.Artist, prev.Title
+			if sorted[i].Duration == 0 {
+				sorted[i].Duration = prev.Duration
+			}
+		}
+	}
+
+	return &ScriptedDetector{
+		clock:     clock.Real,
+		startTime: time.Now(),
+		events:    sorted,
+	}
+}
+
+// SetClock makes the detector tell the time by c, restarting the script at
+// c's current time
+func (d *ScriptedDetector) SetClock(c clock.Clock) {
+	d.clock = c
+	d.startTime = c.Now()
+}
+
+// GetCurrentSong returns the song as of the latest event that has happened
+func (d *ScriptedDetector) GetCurrentSong() (*SongInfo, error) {
+	elapsed := d.clock.Now().Sub(d.startTime)
+
+	// The latest event at or before now
+	i := sort.Search(len(d.events), func(i int) bool { return d.events[i].At > elapsed }) - 1
+	if i < 0 || d.events[i].Stopped {
+		return nil, ErrNoSong
+	}
+	event := d.events[i]
+
+	position := event.Position
+	if event.Playing {
+		position += elapsed - event.At
+	}
+
+	return &SongInfo{
+		Artist:    event.Artist,
+		Title:     event.Title,
+		Album:     "Demo Album",
+		Position:  position,
+		Duration:  event.Duration,
+		IsPlaying: event.Playing,
+	}, nil
+}
+
+// Close is a no-op for the scripted detector
+func (d *ScriptedDetector) Close() error {
 	return nil
 }
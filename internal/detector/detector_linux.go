@@ -3,51 +3,241 @@
 package detector
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/godbus/dbus/v5"
 )
 
+const (
+	// minReconnectDelay is the initial wait before reconnecting to the session bus
+	minReconnectDelay = 1 * time.Second
+	// maxReconnectDelay caps the exponential reconnect backoff
+	maxReconnectDelay = 1 * time.Minute
+	// propertyRetryDelay is the wait before reading a player property again
+	// after it failed, e.g. while the player switches tracks
+	propertyRetryDelay = 50 * time.Millisecond
+)
+
 // LinuxDetector implements song detection using D-Bus MPRIS on Linux
 type LinuxDetector struct {
-	conn *dbus.Conn
+	conn           *dbus.Conn
+	mu             sync.Mutex
+	reconnectDelay time.Duration
+	nextReconnect  time.Time
+	trackers       map[string]*positionTracker // Position estimates per player
+	players        []string                    // MPRIS bus names to check, in order
+	ignored        []string                    // Patterns of players never followed
+	splitTitles    bool                        // Split "Artist - Title" stream titles
+	selection      string                      // Player selection policy, see Options
+	lastTracks     map[string]string           // Last track seen per playing player
+	changedAt      map[string]time.Time        // When each playing player's track last changed
+}
+
+// defaultPlayers are the MPRIS bus names checked when no preference is given
+var defaultPlayers = []string{
+	"org.mpris.MediaPlayer2.spotify",
+	"org.mpris.MediaPlayer2.vlc",
+	"org.mpris.MediaPlayer2.rhythmbox",
+	"org.mpris.MediaPlayer2.chromium",
 }
 
-// NewDetector creates a new platform-specific detector
-func NewDetector() (Detector, error) {
+// noTrack is the MPRIS track ID meaning there is no current track
+const noTrack = dbus.ObjectPath("/org/mpris/MediaPlayer2/TrackList/NoTrack")
+
+// NewDetector creates a new platform-specific detector. If the session bus
+// isn't up yet, e.g. when started at login, connecting is retried when songs
+// are requested. With PlayerctlFallback set and playerctl installed,
+// playerctl is asked when D-Bus finds no song.
+func NewDetector(opts Options) (Detector, error) {
 	conn, err := dbus.ConnectSessionBus()
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to session bus: %w", err)
+		log.Printf("Session bus not available yet, will retry: %v", err)
+		conn = nil
 	}
 
-	return &LinuxDetector{
-		conn: conn,
-	}, nil
+	det := &LinuxDetector{
+		conn:           conn,
+		reconnectDelay: minReconnectDelay,
+		trackers:       make(map[string]*positionTracker),
+		players:        playerOrder(opts.PreferredPlayers, opts.IgnoredPlayers),
+		ignored:        opts.IgnoredPlayers,
+		splitTitles:    opts.SplitStreamTitles,
+		selection:      opts.PlayerSelection,
+		lastTracks:     make(map[string]string),
+		changedAt:      make(map[string]time.Time),
+	}
+
+	if opts.PlayerctlFallback {
+		playerctl, err := NewPlayerctlDetector(opts)
+		if err != nil {
+			log.Printf("playerctl fallback unavailable: %v", err)
+		} else {
+			return NewFallbackDetector(det, playerctl), nil
+		}
+	}
+	return det, nil
+}
+
+// playerOrder returns the bus names to check: preferred players first,
+// followed by the remaining default players, leaving out ignored ones
+func playerOrder(preferred, ignored []string) []string {
+	var players []string
+	seen := make(map[string]bool)
+
+	candidates := append(append([]string{}, preferred...), defaultPlayers...)
+	for _, name := range candidates {
+		if !strings.HasPrefix(name, "org.mpris.MediaPlayer2.") {
+			name = "org.mpris.MediaPlayer2." + name
+		}
+		if !seen[name] && !IsIgnoredPlayer(name, ignored) {
+			seen[name] = true
+			players = append(players, name)
+		}
+	}
+
+	return players
+}
+
+// connection returns a usable session bus connection, reconnecting with
+// exponential backoff if the previous connection was dropped
+func (d *LinuxDetector) connection() (*dbus.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.conn != nil && d.conn.Connected() {
+		return d.conn, nil
+	}
+
+	// Wait out the backoff before trying again
+	if time.Now().Before(d.nextReconnect) {
+		return nil, fmt.Errorf("session bus connection lost, retrying in %v", time.Until(d.nextReconnect).Round(time.Second))
+	}
+
+	if d.conn != nil {
+		d.conn.Close()
+		d.conn = nil
+	}
+
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		d.nextReconnect = time.Now().Add(d.reconnectDelay)
+		d.reconnectDelay *= 2
+		if d.reconnectDelay > maxReconnectDelay {
+			d.reconnectDelay = maxReconnectDelay
+		}
+		return nil, fmt.Errorf("failed to reconnect to session bus: %w", err)
+	}
+
+	log.Println("Reconnected to session bus")
+	d.conn = conn
+	d.reconnectDelay = minReconnectDelay
+	d.nextReconnect = time.Time{}
+	return conn, nil
+}
+
+// Healthy reports whether the session bus connection is currently usable
+func (d *LinuxDetector) Healthy() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.conn != nil && d.conn.Connected()
 }
 
 // GetCurrentSong retrieves the currently playing song from MPRIS-compatible players
 func (d *LinuxDetector) GetCurrentSong() (*SongInfo, error) {
-	// List of common media players to check
-	players := []string{
-		"org.mpris.MediaPlayer2.spotify",
-		"org.mpris.MediaPlayer2.vlc",
-		"org.mpris.MediaPlayer2.rhythmbox",
-		"org.mpris.MediaPlayer2.chromium",
+	conn, err := d.connection()
+	if err != nil {
+		return nil, err
+	}
+
+	if d.selection == "" || d.selection == SelectFirst {
+		for _, player := range d.players {
+			info, err := d.getPlayerInfo(conn, player)
+			if err == nil && info != nil {
+				return info, nil
+			}
+		}
+		return nil, ErrNoSong
 	}
 
-	for _, player := range players {
-		info, err := d.getPlayerInfo(player)
+	// Gather every playing player, in order of preference
+	var names []string
+	var songs []*SongInfo
+	for _, player := range d.allPlayers(conn) {
+		info, err := d.getPlayerInfo(conn, player)
 		if err == nil && info != nil {
-			return info, nil
+			names = append(names, player)
+			songs = append(songs, info)
+		}
+	}
+	d.recordChanges(names, songs, time.Now())
+	if len(songs) == 0 {
+		return nil, ErrNoSong
+	}
+
+	best := 0
+	if d.selection == SelectRecent {
+		d.mu.Lock()
+		for i := range names {
+			if d.changedAt[names[i]].After(d.changedAt[names[best]]) {
+				best = i
+			}
 		}
+		d.mu.Unlock()
 	}
+	return songs[best], nil
+}
+
+// allPlayers returns the configured players followed by every other MPRIS
+// player on the bus, such as browser instances with numbered bus names
+func (d *LinuxDetector) allPlayers(conn *dbus.Conn) []string {
+	var names []string
+	if err := conn.BusObject().Call("org.freedesktop.DBus.ListNames", 0).Store(&names); err != nil {
+		return d.players
+	}
+	sort.Strings(names)
 
-	return nil, fmt.Errorf("no active media player found")
+	players := append([]string{}, d.players...)
+	for _, name := range names {
+		if strings.HasPrefix(name, "org.mpris.MediaPlayer2.") && !slices.Contains(d.players, name) && !IsIgnoredPlayer(name, d.ignored) {
+			players = append(players, name)
+		}
+	}
+	return players
 }
 
-func (d *LinuxDetector) getPlayerInfo(serviceName string) (*SongInfo, error) {
-	obj := d.conn.Object(serviceName, "/org/mpris/MediaPlayer2")
+// recordChanges notes when each playing player's track changed. Players that
+// just started playing count as changed, and stopped ones are forgotten.
+func (d *LinuxDetector) recordChanges(names []string, songs []*SongInfo, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	playing := make(map[string]bool)
+	for i, name := range names {
+		playing[name] = true
+		track := songs[i].Artist + "|" + songs[i].Title
+		if d.lastTracks[name] != track {
+			d.lastTracks[name] = track
+			d.changedAt[name] = now
+		}
+	}
+
+	for name := range d.lastTracks {
+		if !playing[name] {
+			delete(d.lastTracks, name)
+			delete(d.changedAt, name)
+		}
+	}
+}
+
+func (d *LinuxDetector) getPlayerInfo(conn *dbus.Conn, serviceName string) (*SongInfo, error) {
+	obj := conn.Object(serviceName, "/org/mpris/MediaPlayer2")
 
 	// Get playback status
 	statusVariant, err := obj.GetProperty("org.mpris.MediaPlayer2.Player.PlaybackStatus")
@@ -61,7 +251,7 @@ func (d *LinuxDetector) getPlayerInfo(serviceName string) (*SongInfo, error) {
 	}
 
 	// Get metadata
-	metadataVariant, err := obj.GetProperty("org.mpris.MediaPlayer2.Player.Metadata")
+	metadataVariant, err := getPropertyRetry(obj, "org.mpris.MediaPlayer2.Player.Metadata")
 	if err != nil {
 		return nil, err
 	}
@@ -74,6 +264,7 @@ func (d *LinuxDetector) getPlayerInfo(serviceName string) (*SongInfo, error) {
 	// Extract song information
 	info := &SongInfo{
 		IsPlaying: true,
+		Source:    serviceName,
 	}
 
 	if title, ok := metadata["xesam:title"].Value().(string); ok {
@@ -88,8 +279,29 @@ func (d *LinuxDetector) getPlayerInfo(serviceName string) (*SongInfo, error) {
 		info.Album = album
 	}
 
+	// Track ID distinguishes tracks that share an artist and title
+	if trackID, ok := metadata["mpris:trackid"].Value().(dbus.ObjectPath); ok && trackID != noTrack {
+		info.TrackID = string(trackID)
+	} else if trackID, ok := metadata["mpris:trackid"].Value().(string); ok && trackID != string(noTrack) {
+		// Some players send the ID as a plain string
+		info.TrackID = trackID
+	}
+
+	// Cover art is usually a file:// URL to a cached image, or an http URL
+	if artURL, ok := metadata["mpris:artUrl"].Value().(string); ok {
+		info.ArtURL = artURL
+	}
+
+	// Length is in microseconds; players disagree on whether it's signed
+	switch length := metadata["mpris:length"].Value().(type) {
+	case int64:
+		info.Duration = time.Duration(length) * time.Microsecond
+	case uint64:
+		info.Duration = time.Duration(length) * time.Microsecond
+	}
+
 	// Get playback position
-	positionVariant, err := obj.GetProperty("org.mpris.MediaPlayer2.Player.Position")
+	positionVariant, err := getPropertyRetry(obj, "org.mpris.MediaPlayer2.Player.Position")
 	if err == nil {
 		if pos, ok := positionVariant.Value().(int64); ok {
 			// Position is in microseconds, convert to duration
@@ -97,16 +309,121 @@ func (d *LinuxDetector) getPlayerInfo(serviceName string) (*SongInfo, error) {
 		}
 	}
 
+	// Radio streams put the whole "Artist - Title" in the title
+	if info.Artist == "" && d.splitTitles {
+		if artist, title, ok := SplitStreamTitle(info.Title); ok {
+			info.Artist, info.Title = artist, title
+		}
+	}
+
 	// Validate we have at least artist and title
 	if info.Artist == "" || info.Title == "" {
 		return nil, fmt.Errorf("incomplete song information")
 	}
 
+	// Fall back to elapsed time for players that don't report a position
+	d.mu.Lock()
+	tracker, ok := d.trackers[serviceName]
+	if !ok {
+		tracker = &positionTracker{}
+		d.trackers[serviceName] = tracker
+	}
+	info.Position, info.PositionEstimated = tracker.update(info.Artist+"|"+info.Title, info.Position, time.Now())
+	d.mu.Unlock()
+
 	return info, nil
 }
 
+// propertyGetter is the part of dbus.BusObject used to read player properties
+type propertyGetter interface {
+	GetProperty(p string) (dbus.Variant, error)
+}
+
+// permanentPropertyErrors are D-Bus errors that reading a property again
+// won't fix, e.g. a player that doesn't implement Position
+var permanentPropertyErrors = []string{
+	"org.freedesktop.DBus.Error.UnknownProperty",
+	"org.freedesktop.DBus.Error.NotSupported",
+	"org.freedesktop.DBus.Error.InvalidArgs",
+	"org.freedesktop.DBus.Error.ServiceUnknown",
+}
+
+// getPropertyRetry reads a property, trying once more after a short wait if
+// it fails. Players can briefly fail reads while changing tracks, which
+// shouldn't drop a player that is otherwise playing.
+func getPropertyRetry(obj propertyGetter, name string) (dbus.Variant, error) {
+	v, err := obj.GetProperty(name)
+	if err == nil || isPermanentPropertyError(err) {
+		return v, err
+	}
+	time.Sleep(propertyRetryDelay)
+	return obj.GetProperty(name)
+}
+
+// isPermanentPropertyError reports whether err is a D-Bus error that
+// retrying the read won't fix
+func isPermanentPropertyError(err error) bool {
+	var dbusErr dbus.Error
+	if errors.As(err, &dbusErr) {
+		return slices.Contains(permanentPropertyErrors, dbusErr.Name)
+	}
+	var dbusErrPtr *dbus.Error
+	if errors.As(err, &dbusErrPtr) && dbusErrPtr != nil {
+		return slices.Contains(permanentPropertyErrors, dbusErrPtr.Name)
+	}
+	return false
+}
+
+// ListPlayers returns all MPRIS players on the session bus
+func ListPlayers() ([]PlayerInfo, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to session bus: %w", err)
+	}
+	defer conn.Close()
+
+	var names []string
+	if err := conn.BusObject().Call("org.freedesktop.DBus.ListNames", 0).Store(&names); err != nil {
+		return nil, fmt.Errorf("failed to list bus names: %w", err)
+	}
+	sort.Strings(names)
+
+	var players []PlayerInfo
+	for _, name := range names {
+		if !strings.HasPrefix(name, "org.mpris.MediaPlayer2.") {
+			continue
+		}
+
+		player := PlayerInfo{Name: name, Status: "Unknown"}
+		obj := conn.Object(name, "/org/mpris/MediaPlayer2")
+
+		if v, err := obj.GetProperty("org.mpris.MediaPlayer2.Player.PlaybackStatus"); err == nil {
+			if status, ok := v.Value().(string); ok {
+				player.Status = status
+			}
+		}
+
+		if v, err := obj.GetProperty("org.mpris.MediaPlayer2.Player.Metadata"); err == nil {
+			if metadata, ok := v.Value().(map[string]dbus.Variant); ok {
+				if title, ok := metadata["xesam:title"].Value().(string); ok {
+					player.Title = title
+				}
+				if artists, ok := metadata["xesam:artist"].Value().([]string); ok && len(artists) > 0 {
+					player.Artist = artists[0]
+				}
+			}
+		}
+
+		players = append(players, player)
+	}
+
+	return players, nil
+}
+
 // Close closes the D-Bus connection
 func (d *LinuxDetector) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	if d.conn != nil {
 		return d.conn.Close()
 	}
@@ -1,4 +1,4 @@
-//go:build !linux && !darwin && !windows
+//go:build !linux && !windows
 
 package detector
 
@@ -8,7 +8,7 @@ import "fmt"
 type StubDetector struct{}
 
 // NewDetector creates a stub detector for unsupported platforms
-func NewDetector() (Detector, error) {
+func NewDetector(opts Options) (Detector, error) {
 	return nil, fmt.Errorf("song detection not implemented for this platform")
 }
 
@@ -19,3 +19,8 @@ func (d *StubDetector) GetCurrentSong() (*SongInfo, error) {
 func (d *StubDetector) Close() error {
 	return nil
 }
+
+// ListPlayers is not implemented on unsupported platforms
+func ListPlayers() ([]PlayerInfo, error) {
+	return nil, fmt.Errorf("listing players not implemented for this platform")
+}
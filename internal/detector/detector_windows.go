@@ -5,19 +5,25 @@ package detector
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 )
 
 // WindowsDetector uses PowerShell to access Windows Media Transport Controls
 type WindowsDetector struct {
-	lastError error
+	lastError   error
+	splitTitles bool     // Split "Artist - Title" stream titles
+	ignored     []string // Patterns of source apps never followed
 }
 
-// NewDetector creates a new Windows detector
-func NewDetector() (Detector, error) {
-	return &WindowsDetector{}, nil
+// NewDetector creates a new Windows detector. Player preferences and the
+// selection policy don't apply since Windows reports a single current
+// session; when that session belongs to an ignored app, no song is reported.
+func NewDetector(opts Options) (Detector, error) {
+	return &WindowsDetector{splitTitles: opts.SplitStreamTitles, ignored: opts.IgnoredPlayers}, nil
 }
 
 // mediaResult represents the JSON output from PowerShell
@@ -25,9 +31,11 @@ type mediaResult struct {
 	Artist    string  `json:"artist"`
 	Title     string  `json:"title"`
 	Album     string  `json:"album"`
-	Position  float64 `json:"position"`  // Position in seconds
-	Duration  float64 `json:"duration"`  // Duration in seconds
+	Position  float64 `json:"position"` // Position in seconds
+	Duration  float64 `json:"duration"` // Duration in seconds
 	IsPlaying bool    `json:"isPlaying"`
+	ArtPath   string  `json:"artPath"`   // Thumbnail saved to a temporary file
+	SourceApp string  `json:"sourceApp"` // Application id of the session
 }
 
 // GetCurrentSong retrieves the currently playing song from Windows Media Transport Controls
@@ -65,6 +73,26 @@ if ($null -ne $playbackInfo) {
     $isPlaying = $playbackInfo.PlaybackStatus -eq 4  # 4 = Playing
 }
 
+# Save the thumbnail once per track, as reading it on every poll is slow
+$artPath = ""
+if ($null -ne $mediaProps.Thumbnail) {
+    $key = [Text.Encoding]::UTF8.GetBytes("$($mediaProps.Artist)|$($mediaProps.Title)|$($mediaProps.AlbumTitle)")
+    $hash = [BitConverter]::ToString([Security.Cryptography.SHA1]::Create().ComputeHash($key)).Replace("-", "")
+    $artPath = Join-Path ([IO.Path]::GetTempPath()) "lyric-clipboard-art-$hash"
+    if (-not (Test-Path $artPath)) {
+        try {
+            $stream = $mediaProps.Thumbnail.OpenReadAsync().AsTask().GetAwaiter().GetResult()
+            $reader = [IO.WindowsRuntimeStreamExtensions]::AsStreamForRead($stream)
+            $file = [IO.File]::Create($artPath)
+            $reader.CopyTo($file)
+            $file.Close()
+            $reader.Close()
+        } catch {
+            $artPath = ""
+        }
+    }
+}
+
 $result = @{
     artist = $mediaProps.Artist
     title = $mediaProps.Title
@@ -72,6 +100,8 @@ $result = @{
     position = $position
     duration = $duration
     isPlaying = $isPlaying
+    artPath = $artPath
+    sourceApp = $session.SourceAppUserModelId
 }
 
 ConvertTo-Json $result
@@ -93,22 +123,93 @@ ConvertTo-Json $result
 	}
 
 	// Check if we got valid data
-	if result.Title == "" {
-		return nil, nil // No song playing
+	if result.Title == "" || !result.IsPlaying {
+		return nil, ErrNoSong
+	}
+
+	// Some sessions don't name their app; they count as unknown and can't
+	// be ignored
+	source := strings.TrimSpace(result.SourceApp)
+	if source != "" && IsIgnoredPlayer(source, d.ignored) {
+		return nil, ErrNoSong
 	}
 
-	// Convert to SongInfo
+	// Convert to SongInfo. Media Transport Controls have no track
+	// identifier, so TrackID is left empty.
 	songInfo := &SongInfo{
 		Artist:    result.Artist,
 		Title:     result.Title,
 		Album:     result.Album,
 		Position:  time.Duration(result.Position * float64(time.Second)),
+		Duration:  time.Duration(result.Duration * float64(time.Second)),
 		IsPlaying: result.IsPlaying,
+		Source:    source,
+	}
+
+	if result.ArtPath != "" {
+		songInfo.ArtURL = (&url.URL{Scheme: "file", Path: "/" + filepath.ToSlash(result.ArtPath)}).String()
+	}
+
+	// Radio streams put the whole "Artist - Title" in the title
+	if songInfo.Artist == "" && d.splitTitles {
+		if artist, title, ok := SplitStreamTitle(songInfo.Title); ok {
+			songInfo.Artist, songInfo.Title = artist, title
+		}
 	}
 
 	return songInfo, nil
 }
 
+// listPlayersScript lists all media sessions known to Windows Media Transport Controls
+const listPlayersScript = `
+Add-Type -AssemblyName System.Runtime.WindowsRuntime
+$null = [Windows.Media.Control.GlobalSystemMediaTransportControlsSessionManager, Windows.Media.Control, ContentType = WindowsRuntime]
+$null = [Windows.Media.Control.GlobalSystemMediaTransportControlsSession, Windows.Media.Control, ContentType = WindowsRuntime]
+
+$sessionManager = [Windows.Media.Control.GlobalSystemMediaTransportControlsSessionManager]::RequestAsync()
+$sessionManager.AsTask().GetAwaiter().GetResult()
+
+$players = @()
+foreach ($session in $sessionManager.GetSessions()) {
+    $mediaProps = $session.TryGetMediaPropertiesAsync()
+    $mediaProps.AsTask().GetAwaiter().GetResult()
+    $players += @{
+        name = $session.SourceAppUserModelId
+        status = $session.GetPlaybackInfo().PlaybackStatus.ToString()
+        artist = $mediaProps.Artist
+        title = $mediaProps.Title
+    }
+}
+
+ConvertTo-Json -InputObject @($players)
+`
+
+// ListPlayers returns the media sessions known to Windows, identified by
+// their source application id
+func ListPlayers() ([]PlayerInfo, error) {
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", listPlayersScript)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute PowerShell: %w", err)
+	}
+
+	var results []struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+		Artist string `json:"artist"`
+		Title  string `json:"title"`
+	}
+	if err := json.Unmarshal(output, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse session list: %w", err)
+	}
+
+	players := make([]PlayerInfo, 0, len(results))
+	for _, r := range results {
+		players = append(players, PlayerInfo{Name: r.Name, Status: r.Status, Artist: r.Artist, Title: r.Title})
+	}
+	return players, nil
+}
+
 // Close cleans up resources (no-op for Windows detector)
 func (d *WindowsDetector) Close() error {
 	return nil
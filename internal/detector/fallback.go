@@ -0,0 +1,74 @@
+package detector
+
+import (
+	"errors"
+	"sync"
+)
+
+// FallbackDetector asks a primary detector first and a fallback detector
+// when the primary finds no song, e.g. the Spotify Web API when no local
+// player is running
+type FallbackDetector struct {
+	primary  Detector
+	fallback Detector
+
+	mu           sync.Mutex
+	fallbackUsed bool // The fallback found the last song
+}
+
+// NewFallbackDetector creates a detector that tries primary, then fallback
+func NewFallbackDetector(primary, fallback Detector) Detector {
+	return &FallbackDetector{primary: primary, fallback: fallback}
+}
+
+// GetCurrentSong returns the primary detector's song, or the fallback's if
+// the primary has none. The primary's error is returned if both fail.
+func (d *FallbackDetector) GetCurrentSong() (*SongInfo, error) {
+	song, err := d.primary.GetCurrentSong()
+	if err == nil {
+		d.setFallbackUsed(false)
+		return song, nil
+	}
+
+	fallbackSong, fallbackErr := d.fallback.GetCurrentSong()
+	if fallbackErr == nil {
+		d.setFallbackUsed(true)
+		return fallbackSong, nil
+	}
+	d.setFallbackUsed(false)
+
+	// Nothing playing locally is less interesting than the fallback failing
+	if errors.Is(err, ErrNoSong) && !errors.Is(fallbackErr, ErrNoSong) {
+		return nil, fallbackErr
+	}
+	return nil, err
+}
+
+// setFallbackUsed records which detector found the last song
+func (d *FallbackDetector) setFallbackUsed(used bool) {
+	d.mu.Lock()
+	d.fallbackUsed = used
+	d.mu.Unlock()
+}
+
+// Healthy reports whether the primary detector is usable, or the fallback is
+// finding songs in its place
+func (d *FallbackDetector) Healthy() bool {
+	d.mu.Lock()
+	fallbackUsed := d.fallbackUsed
+	d.mu.Unlock()
+
+	if hc, ok := d.primary.(HealthChecker); ok && !fallbackUsed {
+		return hc.Healthy()
+	}
+	return true
+}
+
+// Close closes both detectors
+func (d *FallbackDetector) Close() error {
+	err := d.primary.Close()
+	if fallbackErr := d.fallback.Close(); err == nil {
+		err = fallbackErr
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+//go:build linux
+
+package detector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// playerctlTimeout limits each playerctl call, which can hang if a player
+// stops answering on the bus
+const playerctlTimeout = 2 * time.Second
+
+// playerctlSep separates the fields of playerctlFormat. It is the ASCII unit
+// separator, which doesn't appear in song metadata.
+const playerctlSep = "\x1f"
+
+// playerctlFormat asks playerctl for the fields read by parsePlayerctl.
+// Position and length are in microseconds.
+var playerctlFormat = strings.Join([]string{
+	"{{status}}",
+	"{{playerInstance}}",
+	"{{artist}}",
+	"{{title}}",
+	"{{album}}",
+	"{{position}}",
+	"{{mpris:length}}",
+}, playerctlSep)
+
+// PlayerctlDetector finds the current song by running playerctl. It is a
+// fallback for systems where talking to players over D-Bus directly fails,
+// e.g. inside a sandbox that only allows playerctl through.
+type PlayerctlDetector struct {
+	path        string
+	ignored     []string
+	splitTitles bool
+
+	mu      sync.Mutex
+	tracker positionTracker
+}
+
+// NewPlayerctlDetector creates a detector that runs playerctl, or returns
+// an error if playerctl isn't installed
+func NewPlayerctlDetector(opts Options) (*PlayerctlDetector, error) {
+	path, err := exec.LookPath("playerctl")
+	if err != nil {
+		return nil, fmt.Errorf("playerctl not found: %w", err)
+	}
+
+	return &PlayerctlDetector{
+		path:        path,
+		ignored:     opts.IgnoredPlayers,
+		splitTitles: opts.SplitStreamTitles,
+	}, nil
+}
+
+// GetCurrentSong returns the song of the player playerctl picks, if it is
+// playing
+func (d *PlayerctlDetector) GetCurrentSong() (*SongInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), playerctlTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, d.path, "metadata", "--format", playerctlFormat).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// playerctl exits with an error when there are no players
+			return nil, ErrNoSong
+		}
+		return nil, fmt.Errorf("playerctl failed: %w", err)
+	}
+
+	info, err := d.parse(strings.TrimRight(string(out), "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	info.Position, info.PositionEstimated = d.tracker.update(info.Artist+"|"+info.Title, info.Position, time.Now())
+	d.mu.Unlock()
+
+	return info, nil
+}
+
+// parse reads a line of playerctlFormat output
+func (d *PlayerctlDetector) parse(line string) (*SongInfo, error) {
+	fields := strings.Split(line, playerctlSep)
+	if len(fields) != 7 {
+		return nil, fmt.Errorf("unexpected playerctl output: %q", line)
+	}
+	status, instance, artist, title, album := fields[0], fields[1], fields[2], fields[3], fields[4]
+
+	if status != "Playing" {
+		return nil, ErrNoSong
+	}
+
+	source := "org.mpris.MediaPlayer2." + instance
+	if IsIgnoredPlayer(source, d.ignored) {
+		return nil, ErrNoSong
+	}
+
+	info := &SongInfo{
+		Artist:    artist,
+		Title:     title,
+		Album:     album,
+		Source:    source,
+		IsPlaying: true,
+	}
+	if position, err := strconv.ParseInt(fields[5], 10, 64); err == nil {
+		info.Position = time.Duration(position) * time.Microsecond
+	}
+	if length, err := strconv.ParseInt(fields[6], 10, 64); err == nil {
+		info.Duration = time.Duration(length) * time.Microsecond
+	}
+
+	// Radio streams put the whole "Artist - Title" in the title
+	if info.Artist == "" && d.splitTitles {
+		if artist, title, ok := SplitStreamTitle(info.Title); ok {
+			info.Artist, info.Title = artist, title
+		}
+	}
+
+	if info.Artist == "" || info.Title == "" {
+		return nil, ErrNoSong
+	}
+	return info, nil
+}
+
+// Close is a no-op, as no connection is kept
+func (d *PlayerctlDetector) Close() error {
+	return nil
+}
@@ -0,0 +1,40 @@
+package detector
+
+import "time"
+
+// pauseGap is how long a track can go unobserved before it is assumed to
+// have been paused, so the estimate doesn't count the paused time
+const pauseGap = 2 * time.Second
+
+// positionTracker estimates the playback position from wall-clock time for
+// players that don't report a position (or always report zero)
+type positionTracker struct {
+	trackKey    string
+	started     time.Time
+	lastSeen    time.Time
+	sawPosition bool
+}
+
+// update records an observation of a track with its reported position and
+// returns the position to use and whether it was estimated
+func (t *positionTracker) update(trackKey string, reported time.Duration, now time.Time) (time.Duration, bool) {
+	if trackKey != t.trackKey {
+		// New track, start counting from now
+		t.trackKey = trackKey
+		t.started = now
+		t.sawPosition = false
+	} else if gap := now.Sub(t.lastSeen); gap > pauseGap {
+		// Don't count time the track wasn't playing
+		t.started = t.started.Add(gap)
+	}
+	t.lastSeen = now
+
+	if reported > 0 {
+		t.sawPosition = true
+	}
+	if t.sawPosition {
+		return reported, false
+	}
+
+	return now.Sub(t.started), true
+}
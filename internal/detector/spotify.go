@@ -0,0 +1,295 @@
+package detector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Spotify Web API endpoints
+const (
+	spotifyPlayerURL = "https://api.spotify.com/v1/me/player/currently-playing"
+	spotifyTokenURL  = "https://accounts.spotify.com/api/token"
+)
+
+const (
+	// spotifyPollInterval is the least time between Web API requests. In
+	// between, the position is advanced from the last response, which keeps
+	// well clear of Spotify's rate limits at the app's poll rate.
+	spotifyPollInterval = 2 * time.Second
+	// spotifyRateLimitWait is how long to back off when a 429 response
+	// doesn't say how long to wait
+	spotifyRateLimitWait = 30 * time.Second
+	// spotifyTokenMargin refreshes access tokens this long before they expire
+	spotifyTokenMargin = time.Minute
+)
+
+// SpotifySource is the SongInfo.Source of songs found through the Web API
+const SpotifySource = "spotify-web-api"
+
+// errSpotifyRateLimited is returned while Spotify asks for requests to stop
+var errSpotifyRateLimited = errors.New("spotify rate limit reached")
+
+// SpotifyOptions configures the Spotify Web API detector. Either an access
+// token, or a client ID and refresh token to obtain access tokens with, is
+// required. The token needs the user-read-currently-playing scope.
+type SpotifyOptions struct {
+	AccessToken  string
+	ClientID     string
+	ClientSecret string // Empty for apps using PKCE
+	RefreshToken string
+}
+
+// SpotifyDetector detects the song playing on any of the user's Spotify
+// devices, such as a phone, through the Spotify Web API
+type SpotifyDetector struct {
+	opts   SpotifyOptions
+	client *http.Client
+
+	// Only used by the poll in progress, of which there is at most one
+	accessToken  string
+	tokenExpires time.Time // Zero if unknown, e.g. for a configured token
+
+	mu         sync.Mutex
+	polling    bool      // A poll is in progress
+	retryAt    time.Time // No requests before this, after a 429 response
+	lastPoll   time.Time
+	lastSong   *SongInfo // From the last response, nil if nothing was playing
+	lastSongAt time.Time // When lastSong was requested
+	lastErr    error
+}
+
+// spotifyPlayback is the response of the currently-playing endpoint
+type spotifyPlayback struct {
+	IsPlaying  bool  `json:"is_playing"`
+	ProgressMs int64 `json:"progress_ms"`
+	Item       *struct {
+		ID         string `json:"id"`
+		Name       string `json:"name"`
+		DurationMs int64  `json:"duration_ms"`
+		Artists    []struct {
+			Name string `json:"name"`
+		} `json:"artists"`
+		Album struct {
+			Name   string `json:"name"`
+			Images []struct {
+				URL string `json:"url"`
+			} `json:"images"` // Largest first
+		} `json:"album"`
+	} `json:"item"`
+}
+
+// NewSpotifyDetector creates a detector that polls the Spotify Web API
+func NewSpotifyDetector(opts SpotifyOptions) (Detector, error) {
+	if opts.AccessToken == "" && (opts.ClientID == "" || opts.RefreshToken == "") {
+		return nil, fmt.Errorf("spotify detector needs an access token, or a client ID and refresh token")
+	}
+
+	return &SpotifyDetector{
+		opts:        opts,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		accessToken: opts.AccessToken,
+	}, nil
+}
+
+// GetCurrentSong returns the song playing on Spotify, from the last
+// response with the position advanced by the time since. Requests are made
+// in the background, so a slow or unreachable API doesn't hold up the
+// caller's loop.
+func (d *SpotifyDetector) GetCurrentSong() (*SongInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if !d.polling && now.Sub(d.lastPoll) >= spotifyPollInterval && !now.Before(d.retryAt) {
+		d.lastPoll = now
+		d.polling = true
+		go d.pollInBackground(now)
+	}
+
+	if d.lastErr != nil {
+		return nil, d.lastErr
+	}
+	if d.lastSong == nil {
+		return nil, ErrNoSong
+	}
+
+	song := *d.lastSong
+	if song.IsPlaying {
+		song.Position += now.Sub(d.lastSongAt)
+		if song.Duration > 0 && song.Position > song.Duration {
+			song.Position = song.Duration
+		}
+	}
+	return &song, nil
+}
+
+// pollInBackground polls the Web API and keeps the result for
+// GetCurrentSong. started is when the request was made, which the
+// position in the response is relative to.
+func (d *SpotifyDetector) pollInBackground(started time.Time) {
+	song, err := d.poll()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.polling = false
+
+	// Keep advancing the last song until requests are allowed again
+	if !errors.Is(err, errSpotifyRateLimited) || d.lastSong == nil {
+		d.lastSong, d.lastSongAt, d.lastErr = song, started, err
+	}
+}
+
+// poll requests the currently playing track, refreshing the access token
+// once if it was rejected
+func (d *SpotifyDetector) poll() (*SongInfo, error) {
+	expiring := !d.tokenExpires.IsZero() && time.Now().After(d.tokenExpires.Add(-spotifyTokenMargin))
+	if d.canRefresh() && (d.accessToken == "" || expiring) {
+		if err := d.refreshToken(); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := d.requestPlayback()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && d.canRefresh() {
+		resp.Body.Close()
+		if err := d.refreshToken(); err != nil {
+			return nil, err
+		}
+		if resp, err = d.requestPlayback(); err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent:
+		// Nothing is playing on any device
+		return nil, nil
+	case http.StatusUnauthorized:
+		return nil, fmt.Errorf("spotify rejected the access token, it may have expired")
+	case http.StatusTooManyRequests:
+		wait := spotifyRateLimitWait
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			wait = time.Duration(seconds) * time.Second
+		}
+		d.mu.Lock()
+		d.retryAt = time.Now().Add(wait)
+		d.mu.Unlock()
+		return nil, fmt.Errorf("%w, retrying in %v", errSpotifyRateLimited, wait)
+	default:
+		return nil, fmt.Errorf("spotify returned status %d", resp.StatusCode)
+	}
+
+	var playback spotifyPlayback
+	if err := json.NewDecoder(resp.Body).Decode(&playback); err != nil {
+		return nil, fmt.Errorf("failed to decode spotify response: %w", err)
+	}
+
+	// Ads and podcast episodes have no track
+	if playback.Item == nil || !playback.IsPlaying {
+		return nil, nil
+	}
+
+	var artists []string
+	for _, artist := range playback.Item.Artists {
+		artists = append(artists, artist.Name)
+	}
+
+	var artURL string
+	if images := playback.Item.Album.Images; len(images) > 0 {
+		artURL = images[0].URL
+	}
+
+	return &SongInfo{
+		Artist:    strings.Join(artists, ", "),
+		Title:     playback.Item.Name,
+		Album:     playback.Item.Album.Name,
+		Position:  time.Duration(playback.ProgressMs) * time.Millisecond,
+		Duration:  time.Duration(playback.Item.DurationMs) * time.Millisecond,
+		TrackID:   playback.Item.ID,
+		ArtURL:    artURL,
+		Source:    SpotifySource,
+		IsPlaying: playback.IsPlaying,
+	}, nil
+}
+
+// requestPlayback sends the currently-playing request
+func (d *SpotifyDetector) requestPlayback() (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, spotifyPlayerURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+d.accessToken)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach spotify: %w", err)
+	}
+	return resp, nil
+}
+
+// canRefresh reports whether new access tokens can be obtained
+func (d *SpotifyDetector) canRefresh() bool {
+	return d.opts.ClientID != "" && d.opts.RefreshToken != ""
+}
+
+// refreshToken exchanges the refresh token for a new access token
+func (d *SpotifyDetector) refreshToken() error {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {d.opts.RefreshToken},
+		"client_id":     {d.opts.ClientID},
+	}
+	req, err := http.NewRequest(http.MethodPost, spotifyTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if d.opts.ClientSecret != "" {
+		req.SetBasicAuth(d.opts.ClientID, d.opts.ClientSecret)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to refresh spotify token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to refresh spotify token: status %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken  string `json:"access_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("failed to decode spotify token: %w", err)
+	}
+
+	d.accessToken = token.AccessToken
+	d.tokenExpires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	// Spotify may rotate the refresh token
+	if token.RefreshToken != "" {
+		d.opts.RefreshToken = token.RefreshToken
+	}
+	return nil
+}
+
+// Close releases idle connections
+func (d *SpotifyDetector) Close() error {
+	d.client.CloseIdleConnections()
+	return nil
+}
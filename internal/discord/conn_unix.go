@@ -0,0 +1,35 @@
+//go:build !windows
+
+package discord
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// dial connects to the first Discord IPC socket found. Flatpak and Snap
+// installs put the socket in a subdirectory of the runtime directory.
+func dial() (io.ReadWriteCloser, error) {
+	var dirs []string
+	for _, env := range []string{"XDG_RUNTIME_DIR", "TMPDIR", "TMP", "TEMP"} {
+		if dir := os.Getenv(env); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	dirs = append(dirs, "/tmp")
+
+	for _, dir := range dirs {
+		for _, sub := range []string{"", "app/com.discordapp.Discord", "snap.discord"} {
+			for i := 0; i < 10; i++ {
+				path := filepath.Join(dir, sub, fmt.Sprintf("discord-ipc-%d", i))
+				if conn, err := net.Dial("unix", path); err == nil {
+					return conn, nil
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("discord IPC socket not found")
+}
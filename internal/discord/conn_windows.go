@@ -0,0 +1,18 @@
+package discord
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// dial connects to the first Discord IPC named pipe found
+func dial() (io.ReadWriteCloser, error) {
+	for i := 0; i < 10; i++ {
+		pipe, err := os.OpenFile(fmt.Sprintf(`\\.\pipe\discord-ipc-%d`, i), os.O_RDWR, 0)
+		if err == nil {
+			return pipe, nil
+		}
+	}
+	return nil, fmt.Errorf("discord IPC pipe not found")
+}
@@ -0,0 +1,264 @@
+package discord
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/orchestrator"
+)
+
+const (
+	// updateInterval keeps within Discord's limit of five activity updates
+	// every 20 seconds
+	updateInterval = 4 * time.Second
+	// retryInterval is how long to wait before reconnecting after Discord
+	// could not be reached
+	retryInterval = 15 * time.Second
+	// maxFieldLength is the longest details or state string Discord accepts
+	maxFieldLength = 128
+)
+
+// IPC opcodes
+const (
+	opHandshake = 0
+	opFrame     = 1
+	opClose     = 2
+)
+
+// activity is the Rich Presence payload shown on the user's profile
+type activity struct {
+	Details    string      `json:"details"`
+	State      string      `json:"state,omitempty"`
+	Timestamps *timestamps `json:"timestamps,omitempty"`
+}
+
+// timestamps shows elapsed time since the song started
+type timestamps struct {
+	Start int64 `json:"start"`
+}
+
+// Presence shows the current song and lyric line as Discord Rich Presence.
+// Updates are rate limited, and a Discord client that isn't running is
+// retried periodically without logging.
+type Presence struct {
+	orchestrator *orchestrator.Orchestrator
+	clientID     string
+
+	mu        sync.Mutex
+	pending   *activity // Activity to show, nil to clear
+	sent      *activity // Activity last sent to Discord
+	conn      io.ReadWriteCloser
+	nextRetry time.Time
+	nonce     int
+
+	stopChan chan struct{}
+	done     chan struct{}
+}
+
+// NewPresence creates a Rich Presence updater for the given Discord
+// application client ID
+func NewPresence(orch *orchestrator.Orchestrator, clientID string) *Presence {
+	p := &Presence{
+		orchestrator: orch,
+		clientID:     clientID,
+		stopChan:     make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	orch.AddEventHandler(p.handleEvent)
+	return p
+}
+
+// Start begins sending updates to Discord in the background
+func (p *Presence) Start() {
+	go p.run()
+}
+
+// Close clears the activity and disconnects from Discord
+func (p *Presence) Close() error {
+	close(p.stopChan)
+	<-p.done
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		return nil
+	}
+	if p.sent != nil {
+		p.send(nil)
+	}
+	return p.conn.Close()
+}
+
+// handleEvent records the activity for a song or line change. It runs on
+// the orchestrator's loop, so sending is left to run.
+func (p *Presence) handleEvent(event orchestrator.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if event.Type == orchestrator.EventSongChange {
+		p.pending = &activity{
+			Details:    truncate(fmt.Sprintf("%s - %s", event.Song.Title, event.Song.Artist)),
+			State:      truncate("by " + event.Song.Artist),
+			Timestamps: &timestamps{Start: time.Now().Add(-event.Song.Position).Unix()},
+		}
+		return
+	}
+
+	if p.pending != nil && event.Line != "" {
+		updated := *p.pending
+		updated.State = truncate(event.Line)
+		p.pending = &updated
+	}
+}
+
+// run sends the pending activity every updateInterval until stopped
+func (p *Presence) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(updateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.flush()
+		case <-p.stopChan:
+			return
+		}
+	}
+}
+
+// flush sends the pending activity if it differs from what Discord shows
+func (p *Presence) flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Clear the activity once nothing is playing
+	if p.orchestrator.GetCurrentSongKey() == "" {
+		p.pending = nil
+	}
+	if sameActivity(p.pending, p.sent) {
+		return
+	}
+
+	if p.conn == nil {
+		if time.Now().Before(p.nextRetry) {
+			return
+		}
+		if err := p.connect(); err != nil {
+			// Discord isn't running; try again later without logging
+			p.nextRetry = time.Now().Add(retryInterval)
+			return
+		}
+	}
+
+	if err := p.send(p.pending); err != nil {
+		log.Printf("Discord presence update failed: %v", err)
+		p.conn.Close()
+		p.conn = nil
+		p.sent = nil
+		return
+	}
+	p.sent = p.pending
+}
+
+// connect opens the IPC connection and performs the handshake
+func (p *Presence) connect() error {
+	conn, err := dial()
+	if err != nil {
+		return err
+	}
+
+	handshake := map[string]interface{}{"v": 1, "client_id": p.clientID}
+	if err := writeFrame(conn, opHandshake, handshake); err != nil {
+		conn.Close()
+		return err
+	}
+	// Discord answers with a READY dispatch, or closes on a bad client ID
+	if err := readFrame(conn); err != nil {
+		conn.Close()
+		return err
+	}
+
+	p.conn = conn
+	p.sent = nil
+	return nil
+}
+
+// send sets the activity, or clears it if a is nil
+func (p *Presence) send(a *activity) error {
+	p.nonce++
+	command := map[string]interface{}{
+		"cmd":   "SET_ACTIVITY",
+		"nonce": strconv.Itoa(p.nonce),
+		"args": map[string]interface{}{
+			"pid":      os.Getpid(),
+			"activity": a,
+		},
+	}
+	if err := writeFrame(p.conn, opFrame, command); err != nil {
+		return err
+	}
+	return readFrame(p.conn)
+}
+
+// writeFrame writes a length-prefixed JSON IPC frame
+func writeFrame(w io.Writer, opcode uint32, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	frame := make([]byte, 8+len(data))
+	binary.LittleEndian.PutUint32(frame[0:4], opcode)
+	binary.LittleEndian.PutUint32(frame[4:8], uint32(len(data)))
+	copy(frame[8:], data)
+
+	_, err = w.Write(frame)
+	return err
+}
+
+// readFrame reads and discards one IPC frame, returning an error if Discord
+// closed the connection
+func readFrame(r io.Reader) error {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return err
+	}
+
+	opcode := binary.LittleEndian.Uint32(header[0:4])
+	payload := make([]byte, binary.LittleEndian.Uint32(header[4:8]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return err
+	}
+
+	if opcode == opClose {
+		return fmt.Errorf("discord closed the connection: %s", payload)
+	}
+	return nil
+}
+
+// sameActivity reports whether two activities display the same thing
+func sameActivity(a, b *activity) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Details == b.Details && a.State == b.State
+}
+
+// truncate shortens s to the longest string Discord accepts
+func truncate(s string) string {
+	runes := []rune(s)
+	if len(runes) <= maxFieldLength {
+		return s
+	}
+	return string(runes[:maxFieldLength-3]) + "..."
+}
@@ -0,0 +1,13 @@
+//go:build lyricswindow
+
+package gui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/app"
+)
+
+// newApp creates the Fyne application hosting the lyrics window
+func newApp() fyne.App {
+	return app.NewWithID("com.github.arnavpraneet.lyric-clipboard")
+}
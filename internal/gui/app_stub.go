@@ -0,0 +1,11 @@
+//go:build !lyricswindow
+
+package gui
+
+import "fyne.io/fyne/v2"
+
+// newApp returns nil when the lyrics window is not compiled in.
+// Build with -tags lyricswindow to enable it.
+func newApp() fyne.App {
+	return nil
+}
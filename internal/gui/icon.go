@@ -1,13 +1,78 @@
 package gui
 
-// iconData contains a simple 16x16 ICO format icon
-// This is a musical note icon in base64-encoded ICO format
-var iconData = []byte{
-	// ICO header
-	0x00, 0x00, 0x01, 0x00, 0x01, 0x00, 0x10, 0x10,
-	0x00, 0x00, 0x01, 0x00, 0x20, 0x00, 0x68, 0x04,
-	0x00, 0x00, 0x16, 0x00, 0x00, 0x00,
-	// Simple 16x16 black icon data (simplified)
-	// This would normally contain the actual icon bitmap
-	// For now, using a minimal placeholder
+import (
+	_ "embed"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Icon themes, named after the tray they suit
+const (
+	IconThemeAuto  = "auto"  // Follow the system theme
+	IconThemeLight = "light" // Dark note for light trays
+	IconThemeDark  = "dark"  // Light note for dark trays
+)
+
+// Musical note icons. Windows needs ICO data for the tray, other platforms
+// take PNG.
+var (
+	//go:embed icons/note_dark.png
+	iconDarkPNG []byte
+	//go:embed icons/note_light.png
+	iconLightPNG []byte
+	//go:embed icons/note_dark.ico
+	iconDarkICO []byte
+	//go:embed icons/note_light.ico
+	iconLightICO []byte
+)
+
+// trayIcon returns the icon for the given theme in the platform's format
+func trayIcon(theme string) []byte {
+	if theme != IconThemeLight && theme != IconThemeDark {
+		theme = systemTheme()
+	}
+
+	if runtime.GOOS == "windows" {
+		if theme == IconThemeLight {
+			return iconDarkICO
+		}
+		return iconLightICO
+	}
+	if theme == IconThemeLight {
+		return iconDarkPNG
+	}
+	return iconLightPNG
+}
+
+// systemTheme guesses whether the tray is light or dark, falling back to dark
+// which most desktops use for their panels
+func systemTheme() string {
+	switch runtime.GOOS {
+	case "windows":
+		// SystemUsesLightTheme covers the taskbar, unlike AppsUseLightTheme
+		out, err := exec.Command("reg", "query",
+			`HKCU\Software\Microsoft\Windows\CurrentVersion\Themes\Personalize`,
+			"/v", "SystemUsesLightTheme").Output()
+		if err == nil && strings.Contains(string(out), "0x1") {
+			return IconThemeLight
+		}
+
+	case "darwin":
+		// AppleInterfaceStyle is only set in dark mode
+		if err := exec.Command("defaults", "read", "-g", "AppleInterfaceStyle").Run(); err != nil {
+			return IconThemeLight
+		}
+
+	default:
+		if strings.HasSuffix(strings.ToLower(os.Getenv("GTK_THEME")), ":light") {
+			return IconThemeLight
+		}
+		out, err := exec.Command("gsettings", "get", "org.gnome.desktop.interface", "color-scheme").Output()
+		if err == nil && strings.Contains(string(out), "prefer-light") {
+			return IconThemeLight
+		}
+	}
+	return IconThemeDark
 }
@@ -3,43 +3,105 @@ package gui
 import (
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
+	"fyne.io/fyne/v2"
 	"fyne.io/systray"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/config"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/lyrics"
 	"github.com/arnavpraneet/lyric-clipboard-app/internal/orchestrator"
 )
 
+// configSaveDelay is how long the tray waits after a settings change before
+// saving, so clicking through several offsets writes the file once
+const configSaveDelay = time.Second
+
 // SystemTray manages the system tray icon and menu
 type SystemTray struct {
-	orchestrator   *orchestrator.Orchestrator
-	statusItem     *systray.MenuItem
-	clipboardItem  *systray.MenuItem
-	offsetItems    map[int]*systray.MenuItem
-	currentOffset  time.Duration
+	orchestrator  *orchestrator.Orchestrator
+	config        *config.Config
+	configPath    string
+	statusItem    *systray.MenuItem
+	statsItem     *systray.MenuItem
+	clipboardItem *systray.MenuItem
+	notifyItem    *systray.MenuItem
+	copyLineItem  *systray.MenuItem
+	copyAllItem   *systray.MenuItem
+	exportItem    *systray.MenuItem
+	reloadItem    *systray.MenuItem
+	nextLineItem  *systray.MenuItem
+	prevLineItem  *systray.MenuItem
+	versionsItem  *systray.MenuItem
+	searchItem    *systray.MenuItem
+	versionItems  []*systray.MenuItem
+	versionsSong  string // Song the version items were filled for
+	versionsMu    sync.Mutex
+	lyricsItem    *systray.MenuItem
+	app           fyne.App
+	lyricsWindow  *LyricsWindow
+	offsetItems   map[int]*systray.MenuItem
+	leadItems     map[int]*systray.MenuItem
+	currentOffset time.Duration
+	healthy       bool
+	iconTheme     string
+
+	// Settings changes waiting to be saved, see saveConfig
+	saveMu      sync.Mutex
+	saveTimer   *time.Timer
+	saveChanges []func(*config.Config)
 }
 
-// NewSystemTray creates a new system tray manager
-func NewSystemTray(orch *orchestrator.Orchestrator) *SystemTray {
+// NewSystemTray creates a new system tray manager. Settings changed from the
+// tray are kept in cfg and saved to the config file at configPath, or the
+// default location if configPath is empty.
+func NewSystemTray(orch *orchestrator.Orchestrator, cfg *config.Config, configPath string) *SystemTray {
 	return &SystemTray{
 		orchestrator:  orch,
+		config:        cfg,
+		configPath:    configPath,
 		offsetItems:   make(map[int]*systray.MenuItem),
+		leadItems:     make(map[int]*systray.MenuItem),
 		currentOffset: 0,
+		healthy:       true,
+		iconTheme:     IconThemeAuto,
 	}
 }
 
+// SetIconTheme picks the tray icon variant: IconThemeLight or IconThemeDark
+// for the tray's background, or IconThemeAuto to follow the system theme.
+// It must be called before Run.
+func (st *SystemTray) SetIconTheme(theme string) {
+	st.iconTheme = theme
+}
+
 // Run starts the system tray GUI
 func (st *SystemTray) Run() {
-	systray.Run(st.onReady, st.onExit)
+	st.app = newApp()
+	if st.app == nil {
+		systray.Run(st.onReady, st.onExit)
+		return
+	}
+
+	// Fyne owns the main loop when the lyrics window is available
+	st.lyricsWindow = NewLyricsWindow(st.app, st.orchestrator)
+	st.lyricsWindow.OnClosed = func() {
+		st.lyricsItem.Uncheck()
+	}
+
+	start, end := systray.RunWithExternalLoop(st.onReady, st.onExit)
+	st.app.Lifecycle().SetOnStarted(start)
+	st.app.Lifecycle().SetOnStopped(end)
+	st.app.Run()
 }
 
 // onReady is called when the system tray is ready
 func (st *SystemTray) onReady() {
 	// Set icon and tooltip
-	if len(iconData) > 0 {
-		systray.SetIcon(iconData)
-	}
-	systray.SetTitle("Lyric Clipboard")
-	systray.SetTooltip("Lyric Clipboard - Syncing lyrics to clipboard")
+	systray.SetIcon(trayIcon(st.iconTheme))
+	st.showState(orchestrator.StateIdle)
 
 	// Status display (disabled menu item for display only)
 	st.statusItem = systray.AddMenuItem("Status: Starting...", "Current status")
@@ -49,6 +111,54 @@ func (st *SystemTray) onReady() {
 
 	// Clipboard toggle
 	st.clipboardItem = systray.AddMenuItem("✓ Clipboard Updates", "Enable/disable clipboard updates")
+	if st.orchestrator.GetUpdateClipboard() {
+		st.clipboardItem.Check()
+	} else {
+		st.clipboardItem.SetTitle("☐ Clipboard Updates")
+	}
+
+	// Copy the current line, which is how lines get copied in copy on
+	// demand mode
+	st.copyLineItem = systray.AddMenuItem("Copy Current Line", "Copy the current lyric line")
+	st.copyLineItem.Disable()
+
+	// Copy the whole song's lyrics at once
+	st.copyAllItem = systray.AddMenuItem("Copy All Lyrics", "Copy the full lyrics of the current song")
+	st.copyAllItem.Disable()
+	st.exportItem = systray.AddMenuItem("Export LRC", "Save the current song's lyrics as an LRC file")
+	st.exportItem.Disable()
+
+	// Fetch the lyrics again when the matched ones are wrong
+	st.reloadItem = systray.AddMenuItem("Reload Lyrics", "Fetch the current song's lyrics again")
+	st.reloadItem.Disable()
+
+	// Step through lines by hand when the timing is off
+	st.nextLineItem = systray.AddMenuItem("Next Line", "Show the next lyric line for a few seconds")
+	st.nextLineItem.Disable()
+	st.prevLineItem = systray.AddMenuItem("Previous Line", "Show the previous lyric line for a few seconds")
+	st.prevLineItem.Disable()
+
+	// Pick another lyrics version when the wrong one was matched. Items are
+	// created up front and filled in by a search, as menus can't be rebuilt.
+	st.versionsItem = systray.AddMenuItem("Choose Lyrics Version", "Pick among the lyrics versions found for this song")
+	st.versionsItem.Disable()
+	st.searchItem = st.versionsItem.AddSubMenuItem("Search Versions", "Look up the lyrics versions available for this song")
+	for i := 0; i < orchestrator.MaxLyricsCandidates; i++ {
+		item := st.versionsItem.AddSubMenuItem("", "Use this lyrics version")
+		item.Hide()
+		st.versionItems = append(st.versionItems, item)
+		go st.handleVersionClicks(i, item)
+	}
+
+	// Song change notifications toggle
+	st.notifyItem = systray.AddMenuItemCheckbox("Notifications", "Show a notification when the song changes", st.config.ShowNotifications)
+
+	// Lyrics window toggle
+	st.lyricsItem = systray.AddMenuItemCheckbox("Show Lyrics", "Show a window with the synced lyrics", false)
+	if st.lyricsWindow == nil {
+		st.lyricsItem.SetTooltip("Lyrics window not available in this build")
+		st.lyricsItem.Disable()
+	}
 
 	systray.AddSeparator()
 
@@ -58,13 +168,30 @@ func (st *SystemTray) onReady() {
 	st.offsetItems[-1000] = mOffset.AddSubMenuItem("-1.0s", "Delay lyrics by 1 second")
 	st.offsetItems[-500] = mOffset.AddSubMenuItem("-0.5s", "Delay lyrics by 0.5 seconds")
 	st.offsetItems[0] = mOffset.AddSubMenuItem("0s (None)", "No offset")
-	st.offsetItems[0].Check() // Default
 	st.offsetItems[500] = mOffset.AddSubMenuItem("+0.5s", "Advance lyrics by 0.5 seconds")
 	st.offsetItems[1000] = mOffset.AddSubMenuItem("+1.0s", "Advance lyrics by 1 second")
 	st.offsetItems[2000] = mOffset.AddSubMenuItem("+2.0s", "Advance lyrics by 2 seconds")
+	st.currentOffset = st.orchestrator.GetLyricOffset()
+	if item, ok := st.offsetItems[int(st.currentOffset.Milliseconds())]; ok {
+		item.Check()
+	}
+
+	// Lead time submenu
+	mLead := systray.AddMenuItem("Lead Time", "Show the next line early to read ahead")
+	st.leadItems[0] = mLead.AddSubMenuItem("Off", "Show lines when they are sung")
+	st.leadItems[250] = mLead.AddSubMenuItem("0.25s", "Show the next line 0.25 seconds early")
+	st.leadItems[500] = mLead.AddSubMenuItem("0.5s", "Show the next line 0.5 seconds early")
+	st.leadItems[1000] = mLead.AddSubMenuItem("1.0s", "Show the next line 1 second early")
+	if item, ok := st.leadItems[int(st.orchestrator.GetLeadTime().Milliseconds())]; ok {
+		item.Check()
+	}
 
 	systray.AddSeparator()
 
+	// Cache statistics (display only)
+	st.statsItem = systray.AddMenuItem("Statistics", "Lyrics cache statistics")
+	st.statsItem.Disable()
+
 	// Configuration
 	mConfig := systray.AddMenuItem("Open Config", "Open configuration file")
 
@@ -78,6 +205,13 @@ func (st *SystemTray) onReady() {
 		st.updateStatus(status)
 	})
 
+	// Reflect what the app is doing in the tray's tooltip
+	st.orchestrator.AddEventHandler(func(event orchestrator.Event) {
+		if event.Type == orchestrator.EventStateChange {
+			st.showState(event.State)
+		}
+	})
+
 	// Start orchestrator in background
 	go st.orchestrator.Start()
 
@@ -95,6 +229,42 @@ func (st *SystemTray) handleMenuEvents(mConfig, mQuit *systray.MenuItem) {
 		case <-st.clipboardItem.ClickedCh:
 			st.toggleClipboard()
 
+		case <-st.copyLineItem.ClickedCh:
+			if err := st.orchestrator.CopyCurrentLine(); err != nil {
+				st.updateStatus(fmt.Sprintf("Failed to copy line: %v", err))
+			}
+		case <-st.copyAllItem.ClickedCh:
+			if err := st.orchestrator.CopyAllLyrics(); err != nil {
+				log.Printf("Failed to copy lyrics: %v", err)
+			}
+		case <-st.exportItem.ClickedCh:
+			st.exportLyrics()
+
+		case <-st.reloadItem.ClickedCh:
+			if err := st.orchestrator.ReloadLyrics(); err != nil {
+				st.updateStatus(fmt.Sprintf("Failed to reload lyrics: %v", err))
+			} else {
+				st.updateStatus("Reloading lyrics...")
+			}
+
+		case <-st.nextLineItem.ClickedCh:
+			if err := st.orchestrator.NextLine(); err != nil {
+				st.updateStatus(fmt.Sprintf("Failed to skip line: %v", err))
+			}
+		case <-st.prevLineItem.ClickedCh:
+			if err := st.orchestrator.PrevLine(); err != nil {
+				st.updateStatus(fmt.Sprintf("Failed to skip line: %v", err))
+			}
+
+		case <-st.searchItem.ClickedCh:
+			go st.searchVersions()
+
+		case <-st.notifyItem.ClickedCh:
+			st.toggleNotifications()
+
+		case <-st.lyricsItem.ClickedCh:
+			st.toggleLyricsWindow()
+
 		case <-st.offsetItems[-2000].ClickedCh:
 			st.setOffset(-2000 * time.Millisecond)
 		case <-st.offsetItems[-1000].ClickedCh:
@@ -110,27 +280,185 @@ func (st *SystemTray) handleMenuEvents(mConfig, mQuit *systray.MenuItem) {
 		case <-st.offsetItems[2000].ClickedCh:
 			st.setOffset(2000 * time.Millisecond)
 
+		case <-st.leadItems[0].ClickedCh:
+			st.setLeadTime(0)
+		case <-st.leadItems[250].ClickedCh:
+			st.setLeadTime(250 * time.Millisecond)
+		case <-st.leadItems[500].ClickedCh:
+			st.setLeadTime(500 * time.Millisecond)
+		case <-st.leadItems[1000].ClickedCh:
+			st.setLeadTime(1000 * time.Millisecond)
+
 		case <-mConfig.ClickedCh:
 			st.openConfig()
 
 		case <-mQuit.ClickedCh:
 			log.Println("Quit requested from system tray")
 			systray.Quit()
+			if st.app != nil {
+				st.app.Quit()
+			}
 			return
 		}
 	}
 }
 
-// toggleClipboard toggles clipboard updates
+// handleVersionClicks switches to a lyrics version whenever its item is clicked
+func (st *SystemTray) handleVersionClicks(index int, item *systray.MenuItem) {
+	for range item.ClickedCh {
+		if err := st.orchestrator.ChooseLyricsCandidate(index); err != nil {
+			st.updateStatus(fmt.Sprintf("Failed to switch lyrics: %v", err))
+			continue
+		}
+		for i, other := range st.versionItems {
+			if i == index {
+				other.Check()
+			} else {
+				other.Uncheck()
+			}
+		}
+	}
+}
+
+// searchVersions looks up the current song's lyrics versions and lists them
+// in the version submenu
+func (st *SystemTray) searchVersions() {
+	st.updateStatus("Searching lyrics versions...")
+	song := st.orchestrator.GetCurrentSongKey()
+
+	candidates, err := st.orchestrator.SearchLyricsCandidates()
+	if err != nil {
+		st.updateStatus(fmt.Sprintf("Lyrics search failed: %v", err))
+		return
+	}
+
+	st.versionsMu.Lock()
+	defer st.versionsMu.Unlock()
+	for i, item := range st.versionItems {
+		if i >= len(candidates) {
+			item.Hide()
+			continue
+		}
+		item.SetTitle(candidateTitle(candidates[i]))
+		item.Uncheck()
+		item.Show()
+	}
+	st.versionsSong = song
+	st.updateStatus(fmt.Sprintf("Found %d lyrics versions", len(candidates)))
+}
+
+// clearVersions hides the listed lyrics versions once the song changes
+func (st *SystemTray) clearVersions(song string) {
+	st.versionsMu.Lock()
+	defer st.versionsMu.Unlock()
+
+	if st.versionsSong == "" || song == st.versionsSong {
+		return
+	}
+	for _, item := range st.versionItems {
+		item.Hide()
+	}
+	st.versionsSong = ""
+}
+
+// candidateTitle describes a lyrics version as "Artist — Title — m:ss"
+func candidateTitle(candidate lyrics.Candidate) string {
+	title := fmt.Sprintf("%s — %s", candidate.Artist, candidate.Title)
+	if candidate.Duration > 0 {
+		seconds := int(candidate.Duration.Round(time.Second).Seconds())
+		title += fmt.Sprintf(" — %d:%02d", seconds/60, seconds%60)
+	}
+	if !candidate.Synced {
+		title += " (unsynced)"
+	}
+	return title
+}
+
+// toggleClipboard toggles clipboard updates and saves the choice
 func (st *SystemTray) toggleClipboard() {
-	if st.clipboardItem.Checked() {
+	enabled := !st.clipboardItem.Checked()
+	if enabled {
+		st.clipboardItem.Check()
+		st.clipboardItem.SetTitle("✓ Clipboard Updates")
+	} else {
 		st.clipboardItem.Uncheck()
 		st.clipboardItem.SetTitle("☐ Clipboard Updates")
-		st.orchestrator.SetUpdateClipboard(false)
+	}
+
+	st.orchestrator.SetUpdateClipboard(enabled)
+	st.config.UpdateClipboard = enabled
+	st.saveConfig(func(c *config.Config) {
+		c.UpdateClipboard = enabled
+	})
+}
+
+// toggleNotifications turns song change notifications on or off and saves
+// the choice
+func (st *SystemTray) toggleNotifications() {
+	enabled := !st.notifyItem.Checked()
+	if enabled {
+		st.notifyItem.Check()
 	} else {
-		st.clipboardItem.Check()
-		st.clipboardItem.SetTitle("✓ Clipboard Updates")
-		st.orchestrator.SetUpdateClipboard(true)
+		st.notifyItem.Uncheck()
+	}
+
+	st.orchestrator.SetShowNotifications(enabled)
+	st.config.ShowNotifications = enabled
+	st.saveConfig(func(c *config.Config) {
+		c.ShowNotifications = enabled
+	})
+}
+
+// saveConfig schedules a settings change to be written to the config file.
+// Changes made within configSaveDelay of each other are saved together.
+func (st *SystemTray) saveConfig(change func(*config.Config)) {
+	st.saveMu.Lock()
+	defer st.saveMu.Unlock()
+
+	st.saveChanges = append(st.saveChanges, change)
+	if st.saveTimer != nil {
+		st.saveTimer.Stop()
+	}
+	st.saveTimer = time.AfterFunc(configSaveDelay, st.flushConfig)
+}
+
+// flushConfig writes pending settings changes to the config file
+func (st *SystemTray) flushConfig() {
+	st.saveMu.Lock()
+	defer st.saveMu.Unlock()
+
+	if st.saveTimer != nil {
+		st.saveTimer.Stop()
+		st.saveTimer = nil
+	}
+	if len(st.saveChanges) == 0 {
+		return
+	}
+
+	changes := st.saveChanges
+	st.saveChanges = nil
+	err := config.Update(st.configPath, func(c *config.Config) {
+		for _, change := range changes {
+			change(c)
+		}
+	})
+	if err != nil {
+		log.Printf("Failed to save configuration: %v", err)
+	}
+}
+
+// toggleLyricsWindow shows or hides the lyrics window
+func (st *SystemTray) toggleLyricsWindow() {
+	if st.lyricsWindow == nil {
+		return
+	}
+
+	if st.lyricsItem.Checked() {
+		st.lyricsItem.Uncheck()
+		st.lyricsWindow.Hide()
+	} else {
+		st.lyricsItem.Check()
+		st.lyricsWindow.Show()
 	}
 }
 
@@ -149,10 +477,34 @@ func (st *SystemTray) setOffset(offset time.Duration) {
 
 	st.currentOffset = offset
 	st.orchestrator.SetLyricOffset(offset)
+	st.config.LyricOffset = offset
+	st.saveConfig(func(c *config.Config) {
+		c.LyricOffset = offset
+	})
+}
+
+// setLeadTime sets how early the next line is shown
+func (st *SystemTray) setLeadTime(leadTime time.Duration) {
+	for ms, item := range st.leadItems {
+		if ms == int(leadTime.Milliseconds()) {
+			item.Check()
+		} else {
+			item.Uncheck()
+		}
+	}
+
+	st.orchestrator.SetLeadTime(leadTime)
+	st.config.LeadTime = leadTime
+	st.saveConfig(func(c *config.Config) {
+		c.LeadTime = leadTime
+	})
 }
 
 // updateStatus updates the status display
 func (st *SystemTray) updateStatus(status string) {
+	if st.orchestrator.InQuietHours() {
+		status = "Quiet hours - " + status
+	}
 	if len(status) > 60 {
 		status = status[:60] + "..."
 	}
@@ -167,19 +519,133 @@ func (st *SystemTray) statusUpdateLoop() {
 	for range ticker.C {
 		status := st.orchestrator.GetCurrentStatus()
 		st.updateStatus(status)
+		st.updateHealth()
+
+		if st.orchestrator.HasLyrics() {
+			st.copyLineItem.Enable()
+			st.copyAllItem.Enable()
+			st.exportItem.Enable()
+			st.nextLineItem.Enable()
+			st.prevLineItem.Enable()
+		} else {
+			st.copyLineItem.Disable()
+			st.copyAllItem.Disable()
+			st.exportItem.Disable()
+			st.nextLineItem.Disable()
+			st.prevLineItem.Disable()
+		}
+
+		song := st.orchestrator.GetCurrentSongKey()
+		if song != "" {
+			st.reloadItem.Enable()
+			st.versionsItem.Enable()
+		} else {
+			st.reloadItem.Disable()
+			st.versionsItem.Disable()
+		}
+
+		st.clearVersions(song)
+		st.updateStats()
 	}
 }
 
+// updateStats shows the lyrics cache statistics
+func (st *SystemTray) updateStats() {
+	stats, ok := st.orchestrator.GetCacheStats()
+	if !ok {
+		st.statsItem.Hide()
+		return
+	}
+
+	hitRate := 0
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		hitRate = stats.Hits * 100 / lookups
+	}
+	st.statsItem.SetTitle(fmt.Sprintf("Cache: %d songs, %d%% hits", stats.Entries, hitRate))
+	st.statsItem.SetTooltip(fmt.Sprintf("%d hits, %d misses, %d evictions", stats.Hits, stats.Misses, stats.Evictions))
+}
+
+// updateHealth shows a warning in the tray while the detector is unavailable
+func (st *SystemTray) updateHealth() {
+	healthy := st.orchestrator.DetectorHealthy()
+	if healthy == st.healthy {
+		return
+	}
+	st.healthy = healthy
+
+	if healthy {
+		st.showState(st.orchestrator.GetState())
+	} else {
+		systray.SetTitle("⚠ Lyric Clipboard")
+		systray.SetTooltip("Lyric Clipboard - Media detection unavailable, reconnecting...")
+	}
+}
+
+// showState updates the tray title and tooltip for the orchestrator's state
+func (st *SystemTray) showState(state orchestrator.State) {
+	title := "Lyric Clipboard"
+	var tooltip string
+	switch state {
+	case orchestrator.StateFetching:
+		tooltip = "Lyric Clipboard - Fetching lyrics..."
+	case orchestrator.StateActive:
+		tooltip = "Lyric Clipboard - Syncing lyrics to clipboard"
+	case orchestrator.StateNoLyrics:
+		tooltip = "Lyric Clipboard - No lyrics found for this song"
+	case orchestrator.StateError:
+		title = "⚠ Lyric Clipboard"
+		tooltip = "Lyric Clipboard - Something went wrong, see the log"
+	default:
+		tooltip = "Lyric Clipboard - Waiting for a song"
+	}
+	systray.SetTitle(title)
+	systray.SetTooltip(tooltip)
+}
+
+// exportLyrics saves the current song's lyrics as "Artist - Title.lrc" in
+// the exports directory next to the config file
+func (st *SystemTray) exportLyrics() {
+	song, ok := st.orchestrator.GetCurrentSongInfo()
+	if !ok {
+		st.updateStatus("Failed to export lyrics: no song playing")
+		return
+	}
+
+	configPath, err := config.ResolvePath(st.configPath)
+	if err != nil {
+		st.updateStatus(fmt.Sprintf("Failed to export lyrics: %v", err))
+		return
+	}
+	dir := filepath.Join(filepath.Dir(configPath), "exports")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		st.updateStatus(fmt.Sprintf("Failed to export lyrics: %v", err))
+		return
+	}
+
+	path := filepath.Join(dir, lyrics.LRCFileName(song.Artist, song.Title))
+	if err := st.orchestrator.ExportCurrentLyrics(path); err != nil {
+		st.updateStatus(fmt.Sprintf("Failed to export lyrics: %v", err))
+		return
+	}
+	st.updateStatus("Exported lyrics to " + path)
+}
+
 // openConfig opens the configuration file in the default editor
 func (st *SystemTray) openConfig() {
 	// This would ideally open the config file in the system's default editor
 	// For now, just log the location
-	log.Println("Config file location: ~/.config/lyric-clipboard/config.json")
+	path, err := config.ResolvePath(st.configPath)
+	if err != nil {
+		log.Printf("Failed to find config file: %v", err)
+		return
+	}
+	log.Printf("Config file location: %s", path)
 	// TODO: Implement platform-specific file opening
 }
 
 // onExit is called when the system tray is exiting
 func (st *SystemTray) onExit() {
 	log.Println("System tray exiting...")
+	st.flushConfig()
 	st.orchestrator.Stop()
 }
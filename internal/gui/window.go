@@ -0,0 +1,156 @@
+package gui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/debuglog"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/orchestrator"
+)
+
+// contextLines is the number of lines shown above and below the current one
+const contextLines = 4
+
+// artSize is the size of the cover art above the song name
+const artSize = 96
+
+// LyricsWindow shows the current song's lyrics with the active line highlighted
+type LyricsWindow struct {
+	orchestrator *orchestrator.Orchestrator
+	window       fyne.Window
+	songLabel    *widget.Label
+	lineLabels   []*widget.Label
+	artImage     *canvas.Image
+	artURL       string // Cover art shown or being loaded, empty if none
+
+	// OnClosed is called when the user closes the window
+	OnClosed func()
+}
+
+// NewLyricsWindow creates a hidden lyrics window in the given Fyne app
+func NewLyricsWindow(app fyne.App, orch *orchestrator.Orchestrator) *LyricsWindow {
+	lw := &LyricsWindow{
+		orchestrator: orch,
+		window:       app.NewWindow("Lyric Clipboard"),
+		songLabel:    widget.NewLabelWithStyle("No song detected", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		artImage:     canvas.NewImageFromResource(nil),
+	}
+	lw.artImage.FillMode = canvas.ImageFillContain
+	lw.artImage.SetMinSize(fyne.NewSize(artSize, artSize))
+	lw.artImage.Hide()
+
+	lines := container.NewVBox()
+	for i := 0; i < 2*contextLines+1; i++ {
+		label := widget.NewLabelWithStyle("", fyne.TextAlignCenter, fyne.TextStyle{})
+		label.Wrapping = fyne.TextWrapWord
+		lw.lineLabels = append(lw.lineLabels, label)
+		lines.Add(label)
+	}
+
+	header := container.NewVBox(lw.artImage, lw.songLabel)
+	lw.window.SetContent(container.NewBorder(header, nil, nil, nil, lines))
+	lw.window.Resize(fyne.NewSize(480, 360))
+
+	// Closing the window only hides it so the tray keeps running
+	lw.window.SetCloseIntercept(func() {
+		lw.window.Hide()
+		if lw.OnClosed != nil {
+			lw.OnClosed()
+		}
+	})
+
+	// Follow line changes from the orchestrator
+	orch.AddEventHandler(func(event orchestrator.Event) {
+		fyne.Do(lw.refresh)
+	})
+
+	return lw
+}
+
+// Show displays the window
+func (lw *LyricsWindow) Show() {
+	fyne.Do(func() {
+		lw.refresh()
+		lw.window.Show()
+	})
+}
+
+// Hide hides the window without destroying it
+func (lw *LyricsWindow) Hide() {
+	fyne.Do(lw.window.Hide)
+}
+
+// refresh redraws the lyrics around the current line.
+// Must be called on the Fyne main goroutine.
+func (lw *LyricsWindow) refresh() {
+	songKey := lw.orchestrator.GetCurrentSongKey()
+	if songKey == "" {
+		songKey = "No song detected"
+	}
+	lw.songLabel.SetText(songKey)
+	lw.refreshArt()
+
+	lines, current := lw.orchestrator.GetLyricsContext(contextLines, contextLines)
+
+	// Keep the current line in the middle slot
+	offset := contextLines - current
+	if current < 0 {
+		offset = contextLines + 1
+	}
+
+	for i, label := range lw.lineLabels {
+		idx := i - offset
+		if idx < 0 || idx >= len(lines) {
+			label.SetText("")
+			continue
+		}
+
+		label.SetText(lines[idx].Text)
+		if idx == current {
+			label.TextStyle = fyne.TextStyle{Bold: true}
+			label.Importance = widget.HighImportance
+		} else {
+			label.TextStyle = fyne.TextStyle{}
+			label.Importance = widget.LowImportance
+		}
+		label.Refresh()
+	}
+}
+
+// refreshArt shows the current song's cover art, loading it in the
+// background when the song's art changed. The image is hidden while there
+// is none. Must be called on the Fyne main goroutine.
+func (lw *LyricsWindow) refreshArt() {
+	var artURL string
+	if song, ok := lw.orchestrator.GetCurrentSongInfo(); ok {
+		artURL = song.ArtURL
+	}
+	if artURL == lw.artURL {
+		return
+	}
+
+	lw.artURL = artURL
+	lw.artImage.Hide()
+	if artURL == "" {
+		return
+	}
+
+	go func() {
+		data, loadedURL, err := lw.orchestrator.GetCoverArt()
+		if err != nil {
+			debuglog.Printf("no cover art: %v", err)
+			return
+		}
+
+		fyne.Do(func() {
+			// The song may have changed while loading
+			if loadedURL != lw.artURL {
+				return
+			}
+			lw.artImage.Resource = fyne.NewStaticResource(loadedURL, data)
+			lw.artImage.Refresh()
+			lw.artImage.Show()
+		})
+	}()
+}
@@ -0,0 +1,104 @@
+package hotkey
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrUnsupported is returned by Listen on platforms without global hotkeys
+var ErrUnsupported = errors.New("global hotkeys are not supported on this platform")
+
+// Modifier is a set of modifier keys held with a hotkey
+type Modifier uint8
+
+// Modifier keys
+const (
+	ModCtrl Modifier = 1 << iota
+	ModAlt
+	ModShift
+	ModSuper
+)
+
+// Hotkey is a key combination such as "ctrl+alt+left"
+type Hotkey struct {
+	Modifiers Modifier
+	Key       string // Lowercase key name, see Parse
+}
+
+// Binding runs Action whenever the hotkey described by Keys is pressed
+type Binding struct {
+	Keys   string
+	Action func()
+}
+
+// keyNames lists the keys that hotkeys may use besides letters and digits
+var keyNames = map[string]bool{
+	"left": true, "right": true, "up": true, "down": true,
+	"space": true, "comma": true, "period": true, "minus": true, "equal": true,
+	"f1": true, "f2": true, "f3": true, "f4": true, "f5": true, "f6": true,
+	"f7": true, "f8": true, "f9": true, "f10": true, "f11": true, "f12": true,
+}
+
+// Parse parses a hotkey written as modifiers and a key joined by "+", such
+// as "ctrl+alt+p". Modifiers are ctrl, alt, shift and super; keys are
+// letters, digits, arrow keys, f1-f12, space, comma, period, minus and equal.
+func Parse(s string) (Hotkey, error) {
+	parts := strings.Split(strings.ToLower(strings.ReplaceAll(s, " ", "")), "+")
+
+	var hk Hotkey
+	for i, part := range parts {
+		if i < len(parts)-1 {
+			switch part {
+			case "ctrl", "control":
+				hk.Modifiers |= ModCtrl
+			case "alt":
+				hk.Modifiers |= ModAlt
+			case "shift":
+				hk.Modifiers |= ModShift
+			case "super", "win", "cmd":
+				hk.Modifiers |= ModSuper
+			default:
+				return Hotkey{}, fmt.Errorf("invalid hotkey %q: unknown modifier %q", s, part)
+			}
+			continue
+		}
+
+		isChar := len(part) == 1 && (part[0] >= 'a' && part[0] <= 'z' || part[0] >= '0' && part[0] <= '9')
+		if !isChar && !keyNames[part] {
+			return Hotkey{}, fmt.Errorf("invalid hotkey %q: unknown key %q", s, part)
+		}
+		hk.Key = part
+	}
+
+	if hk.Modifiers == 0 {
+		return Hotkey{}, fmt.Errorf("invalid hotkey %q: at least one modifier is required", s)
+	}
+	return hk, nil
+}
+
+// Listen registers the bindings as global hotkeys and runs their actions
+// until the returned Closer is closed. Bindings with empty Keys are skipped.
+// Returns ErrUnsupported where global hotkeys aren't available, such as
+// under Wayland or on macOS.
+func Listen(bindings []Binding) (io.Closer, error) {
+	var hotkeys []Hotkey
+	var actions []func()
+	for _, binding := range bindings {
+		if binding.Keys == "" {
+			continue
+		}
+		hk, err := Parse(binding.Keys)
+		if err != nil {
+			return nil, err
+		}
+		hotkeys = append(hotkeys, hk)
+		actions = append(actions, binding.Action)
+	}
+	if len(hotkeys) == 0 {
+		return nil, fmt.Errorf("no hotkeys configured")
+	}
+
+	return listen(hotkeys, actions)
+}
@@ -0,0 +1,418 @@
+//go:build linux
+
+package hotkey
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Hotkeys are grabbed with a small X11 client speaking the wire protocol
+// directly, so no C libraries are needed. Wayland has no global hotkeys.
+
+// X11 request opcodes, event codes and modifier masks
+const (
+	x11GrabKey            = 33
+	x11GetInputFocus      = 43
+	x11GetKeyboardMapping = 101
+
+	x11Error    = 0
+	x11Reply    = 1
+	x11KeyPress = 2
+	x11Generic  = 35
+
+	x11BadAccess = 10
+
+	x11ShiftMask   = 1
+	x11LockMask    = 2
+	x11ControlMask = 4
+	x11Mod1Mask    = 8  // Alt
+	x11Mod2Mask    = 16 // Num Lock
+	x11Mod4Mask    = 64 // Super
+)
+
+// keysyms maps key names to X11 keysyms. Letters and digits use their
+// lowercase ASCII code.
+var keysyms = map[string]uint32{
+	"left": 0xff51, "up": 0xff52, "right": 0xff53, "down": 0xff54,
+	"space": 0x20, "comma": 0x2c, "period": 0x2e, "minus": 0x2d, "equal": 0x3d,
+	"f1": 0xffbe, "f2": 0xffbf, "f3": 0xffc0, "f4": 0xffc1, "f5": 0xffc2, "f6": 0xffc3,
+	"f7": 0xffc4, "f8": 0xffc5, "f9": 0xffc6, "f10": 0xffc7, "f11": 0xffc8, "f12": 0xffc9,
+}
+
+// grab is a registered hotkey in X11 terms
+type grab struct {
+	keycode byte
+	mods    uint16
+	action  func()
+}
+
+// listener reads key presses from an X11 connection
+type listener struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	root   uint32
+	grabs  []grab
+	done   chan struct{}
+	once   sync.Once
+}
+
+// listen grabs the hotkeys on the X11 root window
+func listen(hotkeys []Hotkey, actions []func()) (io.Closer, error) {
+	if os.Getenv("XDG_SESSION_TYPE") == "wayland" {
+		return nil, ErrUnsupported
+	}
+	display := os.Getenv("DISPLAY")
+	if display == "" {
+		return nil, ErrUnsupported
+	}
+
+	l, minKeycode, maxKeycode, err := dialX11(display)
+	if err != nil {
+		return nil, err
+	}
+
+	keymap, perKeycode, err := l.keyboardMapping(minKeycode, maxKeycode)
+	if err != nil {
+		l.conn.Close()
+		return nil, err
+	}
+
+	for i, hk := range hotkeys {
+		keycode, ok := findKeycode(keymap, perKeycode, minKeycode, keysym(hk.Key))
+		if !ok {
+			l.conn.Close()
+			return nil, fmt.Errorf("key %q is not on the keyboard", hk.Key)
+		}
+		l.grabs = append(l.grabs, grab{keycode: keycode, mods: x11Modifiers(hk), action: actions[i]})
+	}
+
+	if err := l.grabKeys(); err != nil {
+		l.conn.Close()
+		return nil, err
+	}
+
+	go l.run()
+	return l, nil
+}
+
+// Close releases the grabs by closing the connection
+func (l *listener) Close() error {
+	var err error
+	l.once.Do(func() {
+		err = l.conn.Close()
+		<-l.done
+	})
+	return err
+}
+
+// run dispatches key presses until the connection is closed
+func (l *listener) run() {
+	defer close(l.done)
+
+	// Lock keys don't change which hotkey was pressed
+	relevant := uint16(x11ShiftMask | x11ControlMask | x11Mod1Mask | x11Mod4Mask)
+
+	for {
+		packet, err := l.readPacket()
+		if err != nil {
+			return
+		}
+		if packet[0]&0x7f != x11KeyPress {
+			continue
+		}
+
+		keycode := packet[1]
+		state := binary.LittleEndian.Uint16(packet[28:]) & relevant
+		for _, g := range l.grabs {
+			if g.keycode == keycode && g.mods == state {
+				go g.action()
+			}
+		}
+	}
+}
+
+// grabKeys grabs every hotkey on the root window, once for each combination
+// of Caps Lock and Num Lock so the hotkeys work regardless of them
+func (l *listener) grabKeys() error {
+	lockCombos := []uint16{0, x11LockMask, x11Mod2Mask, x11LockMask | x11Mod2Mask}
+
+	for _, g := range l.grabs {
+		for _, lock := range lockCombos {
+			req := make([]byte, 16)
+			req[0] = x11GrabKey
+			binary.LittleEndian.PutUint16(req[2:], 4)
+			binary.LittleEndian.PutUint32(req[4:], l.root)
+			binary.LittleEndian.PutUint16(req[8:], g.mods|lock)
+			req[10] = g.keycode
+			req[11] = 1 // Asynchronous pointer mode
+			req[12] = 1 // Asynchronous keyboard mode
+			if _, err := l.conn.Write(req); err != nil {
+				return fmt.Errorf("failed to grab hotkey: %w", err)
+			}
+		}
+	}
+
+	// GrabKey has no reply, so make a round trip to collect any errors
+	if _, err := l.conn.Write([]byte{x11GetInputFocus, 0, 1, 0}); err != nil {
+		return fmt.Errorf("failed to grab hotkey: %w", err)
+	}
+	_, err := l.readReply()
+	var protoErr *protocolError
+	if errors.As(err, &protoErr) && protoErr.code == x11BadAccess {
+		return fmt.Errorf("a hotkey is already in use by another application")
+	}
+	return err
+}
+
+// keyboardMapping returns the keysyms for every keycode and the number of
+// keysyms per keycode
+func (l *listener) keyboardMapping(minKeycode, maxKeycode byte) ([]uint32, int, error) {
+	count := int(maxKeycode) - int(minKeycode) + 1
+	req := []byte{x11GetKeyboardMapping, 0, 2, 0, minKeycode, byte(count), 0, 0}
+	if _, err := l.conn.Write(req); err != nil {
+		return nil, 0, fmt.Errorf("failed to read keyboard mapping: %w", err)
+	}
+
+	reply, err := l.readReply()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read keyboard mapping: %w", err)
+	}
+
+	perKeycode := int(reply[1])
+	keymap := make([]uint32, (len(reply)-32)/4)
+	for i := range keymap {
+		keymap[i] = binary.LittleEndian.Uint32(reply[32+4*i:])
+	}
+	return keymap, perKeycode, nil
+}
+
+// readReply reads packets until a reply arrives, skipping events. X11 errors
+// are returned as Go errors.
+func (l *listener) readReply() ([]byte, error) {
+	for {
+		packet, err := l.readPacket()
+		if err != nil {
+			return nil, err
+		}
+		switch packet[0] {
+		case x11Error:
+			return nil, &protocolError{code: packet[1], request: packet[10]}
+		case x11Reply:
+			return packet, nil
+		}
+	}
+}
+
+// protocolError is an error reported by the X server
+type protocolError struct {
+	code    byte
+	request byte
+}
+
+func (e *protocolError) Error() string {
+	return fmt.Sprintf("X11 error %d for request %d", e.code, e.request)
+}
+
+// readPacket reads one reply, error or event, including any extra data
+func (l *listener) readPacket() ([]byte, error) {
+	packet := make([]byte, 32)
+	if _, err := io.ReadFull(l.reader, packet); err != nil {
+		return nil, err
+	}
+
+	// Replies and generic events can be longer than 32 bytes
+	if packet[0] == x11Reply || packet[0]&0x7f == x11Generic {
+		extra := int(binary.LittleEndian.Uint32(packet[4:])) * 4
+		if extra > 0 {
+			packet = append(packet, make([]byte, extra)...)
+			if _, err := io.ReadFull(l.reader, packet[32:]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return packet, nil
+}
+
+// dialX11 connects to the X server named by display and performs the
+// connection setup. Returns the listener with its root window set, and the
+// keyboard's keycode range.
+func dialX11(display string) (*listener, byte, byte, error) {
+	colon := strings.LastIndex(display, ":")
+	if colon < 0 {
+		return nil, 0, 0, fmt.Errorf("invalid DISPLAY %q", display)
+	}
+	host := display[:colon]
+	number := display[colon+1:]
+	if dot := strings.Index(number, "."); dot >= 0 {
+		number = number[:dot]
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("invalid DISPLAY %q", display)
+	}
+
+	var conn net.Conn
+	if host == "" || host == "unix" {
+		conn, err = net.Dial("unix", fmt.Sprintf("/tmp/.X11-unix/X%d", n))
+		if err != nil {
+			// Some servers only listen on the abstract socket
+			conn, err = net.Dial("unix", fmt.Sprintf("@/tmp/.X11-unix/X%d", n))
+		}
+	} else {
+		conn, err = net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(6000+n)))
+	}
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to connect to X server: %w", err)
+	}
+
+	authName, authData := xauthCookie(number)
+
+	// Connection setup, little-endian, protocol version 11.0
+	req := []byte{'l', 0, 11, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint16(req[6:], uint16(len(authName)))
+	binary.LittleEndian.PutUint16(req[8:], uint16(len(authData)))
+	req = append(req, pad4([]byte(authName))...)
+	req = append(req, pad4(authData)...)
+	if _, err := conn.Write(req); err != nil {
+		conn.Close()
+		return nil, 0, 0, fmt.Errorf("failed to set up X11 connection: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	head := make([]byte, 8)
+	if _, err := io.ReadFull(reader, head); err != nil {
+		conn.Close()
+		return nil, 0, 0, fmt.Errorf("failed to set up X11 connection: %w", err)
+	}
+	body := make([]byte, int(binary.LittleEndian.Uint16(head[6:]))*4)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		conn.Close()
+		return nil, 0, 0, fmt.Errorf("failed to set up X11 connection: %w", err)
+	}
+	if head[0] != 1 {
+		conn.Close()
+		reason := body
+		if head[0] == 0 && int(head[1]) <= len(body) {
+			reason = body[:head[1]]
+		}
+		return nil, 0, 0, fmt.Errorf("X server refused connection: %s", strings.TrimSpace(string(reason)))
+	}
+
+	vendorLen := int(binary.LittleEndian.Uint16(body[16:]))
+	numFormats := int(body[21])
+	screen := 32 + len(pad4(make([]byte, vendorLen))) + 8*numFormats
+	if len(body) < screen+4 {
+		conn.Close()
+		return nil, 0, 0, errors.New("X server sent a truncated setup reply")
+	}
+
+	l := &listener{
+		conn:   conn,
+		reader: reader,
+		root:   binary.LittleEndian.Uint32(body[screen:]),
+		done:   make(chan struct{}),
+	}
+	return l, body[26], body[27], nil
+}
+
+// xauthCookie returns the authorization for the local display from the
+// Xauthority file, or empty values if there is none
+func xauthCookie(number string) (string, []byte) {
+	path := os.Getenv("XAUTHORITY")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", nil
+		}
+		path = filepath.Join(home, ".Xauthority")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil
+	}
+	hostname, _ := os.Hostname()
+
+	// Entries are a family followed by address, display number, name and
+	// data, each prefixed with a big-endian length
+	const familyLocal, familyWild = 256, 65535
+	for len(data) >= 2 {
+		family := binary.BigEndian.Uint16(data)
+		data = data[2:]
+		var fields [4][]byte
+		for i := range fields {
+			if len(data) < 2 {
+				return "", nil
+			}
+			size := int(binary.BigEndian.Uint16(data))
+			if len(data) < 2+size {
+				return "", nil
+			}
+			fields[i] = data[2 : 2+size]
+			data = data[2+size:]
+		}
+		address, display, name, cookie := string(fields[0]), string(fields[1]), string(fields[2]), fields[3]
+
+		hostMatches := family == familyWild || (family == familyLocal && address == hostname)
+		if hostMatches && (display == "" || display == number) && name == "MIT-MAGIC-COOKIE-1" {
+			return name, append([]byte(nil), cookie...)
+		}
+	}
+	return "", nil
+}
+
+// keysym returns the X11 keysym for a key name
+func keysym(key string) uint32 {
+	if len(key) == 1 {
+		return uint32(key[0])
+	}
+	return keysyms[key]
+}
+
+// findKeycode returns the keycode that produces sym
+func findKeycode(keymap []uint32, perKeycode int, minKeycode byte, sym uint32) (byte, bool) {
+	if perKeycode == 0 {
+		return 0, false
+	}
+	for i, s := range keymap {
+		if s == sym {
+			return minKeycode + byte(i/perKeycode), true
+		}
+	}
+	return 0, false
+}
+
+// x11Modifiers converts a hotkey's modifiers to an X11 modifier mask
+func x11Modifiers(hk Hotkey) uint16 {
+	var mask uint16
+	if hk.Modifiers&ModCtrl != 0 {
+		mask |= x11ControlMask
+	}
+	if hk.Modifiers&ModAlt != 0 {
+		mask |= x11Mod1Mask
+	}
+	if hk.Modifiers&ModShift != 0 {
+		mask |= x11ShiftMask
+	}
+	if hk.Modifiers&ModSuper != 0 {
+		mask |= x11Mod4Mask
+	}
+	return mask
+}
+
+// pad4 pads b with zeros to a multiple of four bytes
+func pad4(b []byte) []byte {
+	if rem := len(b) % 4; rem != 0 {
+		b = append(b, make([]byte, 4-rem)...)
+	}
+	return b
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+
+package hotkey
+
+import "io"
+
+// listen is not implemented on this platform
+func listen(hotkeys []Hotkey, actions []func()) (io.Closer, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,140 @@
+package hotkey
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	user32                = syscall.NewLazyDLL("user32.dll")
+	kernel32              = syscall.NewLazyDLL("kernel32.dll")
+	procRegisterHotKey    = user32.NewProc("RegisterHotKey")
+	procUnregisterHotKey  = user32.NewProc("UnregisterHotKey")
+	procGetMessageW       = user32.NewProc("GetMessageW")
+	procPostThreadMessage = user32.NewProc("PostThreadMessageW")
+	procGetCurrentThread  = kernel32.NewProc("GetCurrentThreadId")
+)
+
+// Win32 constants used for hotkeys
+const (
+	wmHotkey = 0x0312
+	wmQuit   = 0x0012
+
+	modAlt      = 0x0001
+	modControl  = 0x0002
+	modShift    = 0x0004
+	modWin      = 0x0008
+	modNoRepeat = 0x4000
+)
+
+// virtualKeys maps key names to Windows virtual-key codes. Letters and digits
+// use their uppercase ASCII code.
+var virtualKeys = map[string]uintptr{
+	"left": 0x25, "up": 0x26, "right": 0x27, "down": 0x28,
+	"space": 0x20, "comma": 0xBC, "period": 0xBE, "minus": 0xBD, "equal": 0xBB,
+	"f1": 0x70, "f2": 0x71, "f3": 0x72, "f4": 0x73, "f5": 0x74, "f6": 0x75,
+	"f7": 0x76, "f8": 0x77, "f9": 0x78, "f10": 0x79, "f11": 0x7A, "f12": 0x7B,
+}
+
+// msg mirrors the Win32 MSG structure
+type msg struct {
+	hwnd    uintptr
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	ptX     int32
+	ptY     int32
+}
+
+// listener receives WM_HOTKEY messages on a dedicated OS thread, since
+// hotkeys are delivered to the thread that registered them
+type listener struct {
+	threadID uintptr
+	done     chan struct{}
+}
+
+// listen registers the hotkeys with RegisterHotKey
+func listen(hotkeys []Hotkey, actions []func()) (io.Closer, error) {
+	l := &listener{done: make(chan struct{})}
+	ready := make(chan error, 1)
+
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		defer close(l.done)
+
+		l.threadID, _, _ = procGetCurrentThread.Call()
+
+		for i, hk := range hotkeys {
+			if ret, _, err := procRegisterHotKey.Call(0, uintptr(i+1), modifiers(hk), virtualKey(hk.Key)); ret == 0 {
+				for j := 0; j < i; j++ {
+					procUnregisterHotKey.Call(0, uintptr(j+1))
+				}
+				ready <- fmt.Errorf("failed to register hotkey %s: %v", hk.Key, err)
+				return
+			}
+		}
+		ready <- nil
+
+		defer func() {
+			for i := range hotkeys {
+				procUnregisterHotKey.Call(0, uintptr(i+1))
+			}
+		}()
+
+		// GetMessage returns 0 for WM_QUIT and -1 on error
+		var m msg
+		for {
+			ret, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+			if ret == 0 || int32(ret) == -1 {
+				return
+			}
+			if m.message == wmHotkey && m.wParam >= 1 && int(m.wParam) <= len(actions) {
+				go actions[m.wParam-1]()
+			}
+		}
+	}()
+
+	if err := <-ready; err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Close unregisters the hotkeys and stops the message loop
+func (l *listener) Close() error {
+	procPostThreadMessage.Call(l.threadID, wmQuit, 0, 0)
+	<-l.done
+	return nil
+}
+
+// modifiers converts a hotkey's modifiers to RegisterHotKey flags
+func modifiers(hk Hotkey) uintptr {
+	flags := uintptr(modNoRepeat)
+	if hk.Modifiers&ModCtrl != 0 {
+		flags |= modControl
+	}
+	if hk.Modifiers&ModAlt != 0 {
+		flags |= modAlt
+	}
+	if hk.Modifiers&ModShift != 0 {
+		flags |= modShift
+	}
+	if hk.Modifiers&ModSuper != 0 {
+		flags |= modWin
+	}
+	return flags
+}
+
+// virtualKey returns the virtual-key code for a key name
+func virtualKey(key string) uintptr {
+	if len(key) == 1 {
+		return uintptr(strings.ToUpper(key)[0])
+	}
+	return virtualKeys[key]
+}
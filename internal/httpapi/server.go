@@ -0,0 +1,294 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/orchestrator"
+)
+
+// eventBufferSize is how many events a slow WebSocket client may fall behind
+// before further events are dropped for it
+const eventBufferSize = 16
+
+// Current is the response of GET /current
+type Current struct {
+	Status string `json:"status"`
+	State  string `json:"state"`
+	Artist string `json:"artist,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Album  string `json:"album,omitempty"`
+	Line   string `json:"line"`
+	Paused bool   `json:"paused"`
+
+	// Timing for clients that animate progress through the line themselves.
+	// The line starts are null before the first line and after the last one.
+	PositionMs      int64  `json:"position_ms"`
+	LineStartMs     *int64 `json:"line_start_ms"`
+	NextLineStartMs *int64 `json:"next_line_start_ms"`
+}
+
+// Health is the response of GET /healthz
+type Health struct {
+	Status          string     `json:"status"` // "ok", or "degraded" when the detector is unusable
+	DetectorHealthy bool       `json:"detector_healthy"`
+	SongDetected    bool       `json:"song_detected"`
+	LastFetch       *time.Time `json:"last_fetch"` // Null until lyrics have been loaded
+	CacheSize       int        `json:"cache_size"`
+}
+
+// EventMessage is pushed to WebSocket clients on every song, line and state
+// change
+type EventMessage struct {
+	Event  string `json:"event"`
+	Artist string `json:"artist"`
+	Title  string `json:"title"`
+	Album  string `json:"album,omitempty"`
+	Line   string `json:"line,omitempty"`
+	State  string `json:"state,omitempty"`
+}
+
+// Server exposes the current song and lyric line over HTTP, for browser
+// overlays and widgets.
+//
+//	GET /current  returns the current song and line as JSON
+//	GET /healthz  returns the detector and lyrics cache status as JSON,
+//	              with status 503 while the detector is unusable
+//	GET /ws       upgrades to a WebSocket that receives an EventMessage for
+//	              every song, line and state change
+type Server struct {
+	orchestrator *orchestrator.Orchestrator
+	addr         string
+	server       *http.Server
+	clients      map[chan orchestrator.Event]struct{}
+	mu           sync.Mutex
+}
+
+// NewServer creates an HTTP server listening on the given address
+func NewServer(orch *orchestrator.Orchestrator, addr string) *Server {
+	s := &Server{
+		orchestrator: orch,
+		addr:         addr,
+		clients:      make(map[chan orchestrator.Event]struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/current", s.handleCurrent)
+	mux.HandleFunc("/healthz", s.handleHealth)
+	mux.HandleFunc("/ws", s.handleWebSocket)
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	// Fan out orchestrator events to WebSocket clients
+	orch.AddEventHandler(s.broadcast)
+
+	return s
+}
+
+// Start begins serving requests in the background
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.addr, err)
+	}
+
+	log.Printf("HTTP API listening on http://%s", listener.Addr())
+	go s.server.Serve(listener)
+	return nil
+}
+
+// Close stops the server and disconnects all WebSocket clients
+func (s *Server) Close() error {
+	err := s.server.Close()
+
+	s.mu.Lock()
+	for events := range s.clients {
+		close(events)
+	}
+	s.clients = make(map[chan orchestrator.Event]struct{})
+	s.mu.Unlock()
+
+	return err
+}
+
+// handleCurrent returns the current song and line
+func (s *Server) handleCurrent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	current := Current{
+		Status: s.orchestrator.GetCurrentStatus(),
+		State:  s.orchestrator.GetState().String(),
+		Line:   s.orchestrator.GetCurrentLine(),
+		Paused: s.orchestrator.IsPaused(),
+	}
+	if song, ok := s.orchestrator.GetCurrentSongInfo(); ok {
+		current.Artist = song.Artist
+		current.Title = song.Title
+		current.Album = song.Album
+		current.PositionMs = s.orchestrator.GetPosition().Milliseconds()
+	}
+
+	lines, index := s.orchestrator.GetLyricsContext(0, 1)
+	if index >= 0 {
+		start := lines[index].Time.Milliseconds()
+		current.LineStartMs = &start
+	}
+	if next := index + 1; next < len(lines) {
+		start := lines[next].Time.Milliseconds()
+		current.NextLineStartMs = &start
+	}
+
+	// Overlays are often loaded from local files, so allow any origin
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	writeJSON(w, r, http.StatusOK, current)
+}
+
+// handleHealth reports whether the app is able to follow the player
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	health := Health{
+		Status:          "ok",
+		DetectorHealthy: s.orchestrator.DetectorHealthy(),
+	}
+	_, health.SongDetected = s.orchestrator.GetCurrentSongInfo()
+	if lastFetch := s.orchestrator.GetLastFetchTime(); !lastFetch.IsZero() {
+		health.LastFetch = &lastFetch
+	}
+	if stats, ok := s.orchestrator.GetCacheStats(); ok {
+		health.CacheSize = stats.Entries
+	}
+
+	status := http.StatusOK
+	if !health.DetectorHealthy {
+		health.Status = "degraded"
+		status = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, r, status, health)
+}
+
+// writeJSON writes v as the response body. Output is compact unless the
+// request asks for it indented with ?pretty=1, for reading in a browser
+// or terminal.
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	var data []byte
+	var err error
+	if pretty, _ := strconv.ParseBool(r.URL.Query().Get("pretty")); pretty {
+		data, err = json.MarshalIndent(v, "", "  ")
+	} else {
+		data, err = json.Marshal(v)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
+
+// handleWebSocket streams events to a client until it disconnects
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	events := make(chan orchestrator.Event, eventBufferSize)
+	s.mu.Lock()
+	s.clients[events] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, events)
+		s.mu.Unlock()
+	}()
+
+	// Send the current state so new clients don't wait for the next change
+	if song, ok := s.orchestrator.GetCurrentSongInfo(); ok {
+		event := orchestrator.Event{
+			Type: orchestrator.EventLineChange,
+			Song: *song,
+			Line: s.orchestrator.GetCurrentLine(),
+		}
+		if err := s.send(conn, event); err != nil {
+			return
+		}
+	}
+
+	// Detect disconnects, since clients only send control frames
+	closed := make(chan struct{})
+	go func() {
+		conn.readLoop()
+		close(closed)
+	}()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				// Server is shutting down
+				return
+			}
+			if err := s.send(conn, event); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// send writes an event to a WebSocket client
+func (s *Server) send(conn *wsConn, event orchestrator.Event) error {
+	// Only state events carry a state
+	var state string
+	if event.Type == orchestrator.EventStateChange {
+		state = event.State.String()
+	}
+
+	data, err := json.Marshal(EventMessage{
+		Event:  event.Type.String(),
+		Artist: event.Song.Artist,
+		Title:  event.Song.Title,
+		Album:  event.Song.Album,
+		Line:   event.Line,
+		State:  state,
+	})
+	if err != nil {
+		return err
+	}
+	return conn.WriteText(data)
+}
+
+// broadcast delivers an event to all WebSocket clients without blocking
+func (s *Server) broadcast(event orchestrator.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for events := range s.clients {
+		select {
+		case events <- event:
+		default:
+			// Client is too slow, drop the event
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package lyrics
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultAnnotationPatterns match common annotations in lyrics: *chorus*
+// style asterisks, [Verse 1] style brackets, and parentheses naming a song
+// section or repeat count, such as (chorus) or (x2). Other parentheses are
+// usually sung, e.g. backing vocals, so they are kept.
+var DefaultAnnotationPatterns = []string{
+	`\*[^*]+\*`,
+	`\[[^\]]*\]`,
+	`(?i)\((?:chorus|pre-chorus|verse|bridge|intro|outro|hook|refrain|repeat|instrumental|spoken|x\s*\d+|\d+\s*x)[^)]*\)`,
+}
+
+// CompileAnnotationPatterns compiles annotation regular expressions for
+// StripAnnotations, using DefaultAnnotationPatterns if patterns is empty
+func CompileAnnotationPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		patterns = DefaultAnnotationPatterns
+	}
+
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid annotation pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// StripAnnotations removes text matching the patterns from each line, in
+// place. The original text is kept in Raw. Lines left without text are
+// dropped, like the timestamp-only lines ParseLRC skips.
+func (sl *SyncedLyrics) StripAnnotations(patterns []*regexp.Regexp) {
+	lines := sl.Lines[:0]
+	for _, line := range sl.Lines {
+		text := line.Text
+		for _, re := range patterns {
+			text = re.ReplaceAllString(text, "")
+		}
+		text = strings.Join(strings.Fields(text), " ")
+
+		if text != line.Text {
+			if text == "" && line.Text != "" {
+				continue
+			}
+			line.Raw = line.Text
+			line.Text = text
+		}
+		lines = append(lines, line)
+	}
+	sl.Lines = lines
+}
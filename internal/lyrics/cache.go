@@ -0,0 +1,132 @@
+package lyrics
+
+import (
+	"container/list"
+	"time"
+)
+
+// DefaultCacheMaxEntries is the default number of songs kept in the cache
+const DefaultCacheMaxEntries = 500
+
+// DefaultNegativeCacheTTL is how long a song without lyrics is remembered
+// before the source is asked again
+const DefaultNegativeCacheTTL = time.Hour
+
+// CacheStats describes how well the lyrics cache is working
+type CacheStats struct {
+	Hits      int `json:"hits"`      // Lookups answered from the cache, including songs known to have no lyrics
+	Misses    int `json:"misses"`    // Lookups that went to the source
+	Entries   int `json:"entries"`   // Songs currently cached
+	Evictions int `json:"evictions"` // Songs dropped to stay within the size limit
+
+	// Revalidated counts lookups the source answered with 304 Not Modified,
+	// reusing an earlier response
+	Revalidated int `json:"revalidated"`
+}
+
+// lyricsCache is a least-recently-used cache of fetched lyrics.
+// It is not safe for concurrent use; Fetcher guards it with its mutex.
+type lyricsCache struct {
+	maxEntries int // Zero or less means unbounded
+	entries    map[string]*list.Element
+	order      *list.List // Most recently used at the front
+	stats      CacheStats // Entries is filled in by Fetcher.Stats
+}
+
+// cacheEntry is the value stored in each list element. Songs without lyrics
+// are cached as tombstones holding the error and an expiry time.
+type cacheEntry struct {
+	key     string
+	lyrics  *SyncedLyrics
+	err     error
+	expires time.Time
+}
+
+// newLyricsCache creates a cache holding at most maxEntries songs
+func newLyricsCache(maxEntries int) *lyricsCache {
+	return &lyricsCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// get returns the cached lyrics for key, or the error cached for a song
+// without lyrics, and marks the entry as recently used
+func (c *lyricsCache) get(key string, now time.Time) (*SyncedLyrics, error, bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if entry.err != nil && now.After(entry.expires) {
+		c.remove(key)
+		c.stats.Misses++
+		return nil, nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	c.stats.Hits++
+	return entry.lyrics, entry.err, true
+}
+
+// add stores lyrics under key, evicting the least recently used entries
+// if the cache is full
+func (c *lyricsCache) add(key string, lyrics *SyncedLyrics) {
+	c.put(&cacheEntry{key: key, lyrics: lyrics})
+}
+
+// addMiss stores a tombstone recording that key has no lyrics until expires
+func (c *lyricsCache) addMiss(key string, err error, expires time.Time) {
+	c.put(&cacheEntry{key: key, err: err, expires: expires})
+}
+
+// put stores an entry, replacing any existing one for the same key
+func (c *lyricsCache) put(entry *cacheEntry) {
+	if elem, ok := c.entries[entry.key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[entry.key] = c.order.PushFront(entry)
+	c.evict()
+}
+
+// removeMisses deletes all tombstones
+func (c *lyricsCache) removeMisses() {
+	for key, elem := range c.entries {
+		if elem.Value.(*cacheEntry).err != nil {
+			c.remove(key)
+		}
+	}
+}
+
+// remove deletes the entry for key, if any
+func (c *lyricsCache) remove(key string) {
+	if elem, ok := c.entries[key]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+// setMaxEntries changes the cache size, evicting entries if it shrank
+func (c *lyricsCache) setMaxEntries(maxEntries int) {
+	c.maxEntries = maxEntries
+	c.evict()
+}
+
+// evict removes the least recently used entries until the cache fits
+func (c *lyricsCache) evict() {
+	if c.maxEntries <= 0 {
+		return
+	}
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+		c.stats.Evictions++
+	}
+}
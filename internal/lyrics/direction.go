@@ -0,0 +1,62 @@
+package lyrics
+
+import "unicode"
+
+// Direction is the writing direction of a line of text
+type Direction int
+
+const (
+	// LeftToRight is used for Latin, CJK and most other scripts, and for
+	// text without letters
+	LeftToRight Direction = iota
+	// RightToLeft is used for Arabic, Hebrew and other right-to-left scripts
+	RightToLeft
+)
+
+// String returns "ltr" or "rtl", as used by HTML's dir attribute
+func (d Direction) String() string {
+	if d == RightToLeft {
+		return "rtl"
+	}
+	return "ltr"
+}
+
+// rtlScripts are the scripts written right to left
+var rtlScripts = []*unicode.RangeTable{
+	unicode.Arabic,
+	unicode.Hebrew,
+	unicode.Syriac,
+	unicode.Thaana,
+	unicode.Nko,
+	unicode.Samaritan,
+	unicode.Mandaic,
+	unicode.Adlam,
+}
+
+// Direction returns the line's writing direction, decided by whether most of
+// its letters are from right-to-left scripts. Mixed lines such as an Arabic
+// verse with an English word in it follow the dominant script.
+func (l LyricLine) Direction() Direction {
+	return TextDirection(l.Text)
+}
+
+// TextDirection returns the writing direction of text, decided by whether
+// most of its letters are from right-to-left scripts
+func TextDirection(text string) Direction {
+	var rtl, ltr int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if unicode.IsOneOf(rtlScripts, r) {
+			rtl++
+		} else {
+			ltr++
+		}
+	}
+
+	if rtl > ltr {
+		return RightToLeft
+	}
+	return LeftToRight
+}
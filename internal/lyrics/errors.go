@@ -0,0 +1,32 @@
+package lyrics
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrLyricsNotFound is returned when the source has no matching track
+var ErrLyricsNotFound = errors.New("lyrics not found")
+
+// ErrNoSyncedLyrics is returned when the source knows the track but has no
+// lyrics text for it, synced or plain
+var ErrNoSyncedLyrics = errors.New("no lyrics available for this song")
+
+// ErrRateLimited is returned when too many lyrics requests were made
+// recently to send another before the lookup's deadline
+var ErrRateLimited = errors.New("too many lyrics requests, try again shortly")
+
+// ErrLyricsTooLarge is returned for lyrics beyond the size or line count
+// limits of ParseOptions, which no real song comes near
+var ErrLyricsTooLarge = errors.New("lyrics too large")
+
+// FetchError is returned when the lyrics API responds with an unexpected
+// HTTP status
+type FetchError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("API returned status %d: %s", e.StatusCode, e.Body)
+}
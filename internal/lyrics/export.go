@@ -0,0 +1,79 @@
+package lyrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// standardTags are the LRC ID tags written first, in this order
+var standardTags = []string{"ar", "ti", "al", "length"}
+
+// FormatLRC returns the lyrics in LRC format, so that parsing the result
+// gives the same lines and tags. Times are written as [mm:ss.xx], or with
+// milliseconds when they aren't whole hundredths.
+func (sl *SyncedLyrics) FormatLRC() string {
+	var b strings.Builder
+
+	tags := make(map[string]string, len(sl.Metadata)+4)
+	for tag, value := range sl.Metadata {
+		tags[tag] = value
+	}
+	for tag, value := range map[string]string{"ar": sl.Artist, "ti": sl.Title, "al": sl.Album} {
+		if value != "" {
+			tags[tag] = value
+		}
+	}
+	if _, ok := tags["length"]; !ok && sl.Length > 0 {
+		tags["length"] = formatLength(sl.Length)
+	}
+
+	var others []string
+	for tag := range tags {
+		if !isStandardTag(tag) {
+			others = append(others, tag)
+		}
+	}
+	sort.Strings(others)
+
+	for _, tag := range append(standardTags, others...) {
+		if value, ok := tags[tag]; ok {
+			fmt.Fprintf(&b, "[%s:%s]\n", tag, value)
+		}
+	}
+
+	for _, line := range sl.Lines {
+		fmt.Fprintf(&b, "%s%s\n", formatTimestamp(line.Time), line.Text)
+	}
+	return b.String()
+}
+
+// formatTimestamp formats a line time as an LRC timestamp
+func formatTimestamp(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	minutes, seconds, fraction := ms/60000, ms/1000%60, ms%1000
+	if fraction%10 == 0 {
+		return fmt.Sprintf("[%02d:%02d.%02d]", minutes, seconds, fraction/10)
+	}
+	return fmt.Sprintf("[%02d:%02d.%03d]", minutes, seconds, fraction)
+}
+
+// formatLength formats a song length for the [length:] tag
+func formatLength(d time.Duration) string {
+	seconds := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
+
+// isStandardTag reports whether tag is one of standardTags
+func isStandardTag(tag string) bool {
+	for _, standard := range standardTags {
+		if tag == standard {
+			return true
+		}
+	}
+	return false
+}
@@ -1,134 +1,477 @@
 package lyrics
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"sync"
 	"time"
+
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/debuglog"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/version"
+)
+
+// DefaultUserAgent identifies the app to lyrics APIs, as lrclib asks clients to do
+var DefaultUserAgent = fmt.Sprintf("lyric-clipboard-app/%s (+https://github.com/arnavpraneet/lyric-clipboard-app)", version.Version)
+
+// Fetch timeouts
+const (
+	// DefaultRequestTimeout limits a single HTTP request
+	DefaultRequestTimeout = 10 * time.Second
+	// DefaultFetchTimeout limits a whole lookup, including retries
+	DefaultFetchTimeout = 20 * time.Second
 )
 
 // Fetcher handles fetching and caching of song lyrics
 type Fetcher struct {
-	client *http.Client
-	cache  map[string]*SyncedLyrics
-	mu     sync.RWMutex
+	client       *http.Client
+	userAgent    string
+	missTTL      time.Duration
+	fetchTimeout time.Duration
+	cache        *lyricsCache
+	noCache      bool            // Skip the cache entirely, see SetCacheEnabled
+	limiter      *rateLimiter    // Limits outbound requests, nil for no limit
+	validators   *validatorStore // For conditional requests to lrclib
+	localDB      *LocalDB        // Searched before lrclib, nil if not set
+	mu           sync.RWMutex
 }
 
 // NewFetcher creates a new lyrics fetcher with caching
 func NewFetcher() *Fetcher {
 	return &Fetcher{
 		client: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout: DefaultRequestTimeout,
 		},
-		cache: make(map[string]*SyncedLyrics),
+		userAgent:    DefaultUserAgent,
+		missTTL:      DefaultNegativeCacheTTL,
+		fetchTimeout: DefaultFetchTimeout,
+		cache:        newLyricsCache(DefaultCacheMaxEntries),
+		limiter:      newRateLimiter(DefaultRateLimit, rateLimitBurst),
+		validators:   newValidatorStore(),
 	}
 }
 
-// getCacheKey generates a cache key from artist and title
-func (f *Fetcher) getCacheKey(artist, title string) string {
-	return fmt.Sprintf("%s|||%s", artist, title)
+// SetRequestTimeout limits how long a single HTTP request may take
+func (f *Fetcher) SetRequestTimeout(timeout time.Duration) {
+	f.client.Timeout = timeout
+}
+
+// SetFetchTimeout limits how long a lookup may take across all requests and
+// retries. Zero or less removes the limit.
+func (f *Fetcher) SetFetchTimeout(timeout time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fetchTimeout = timeout
+}
+
+// SetUserAgent overrides the User-Agent sent with API requests
+func (f *Fetcher) SetUserAgent(userAgent string) {
+	f.userAgent = userAgent
+}
+
+// SetCacheEnabled turns caching on or off. While off, every fetch goes to
+// the source and nothing is added to the cache.
+func (f *Fetcher) SetCacheEnabled(enabled bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.noCache = !enabled
+}
+
+// SetRateLimit limits how many requests per second are sent to the lyrics
+// API. Requests beyond the limit wait their turn, failing with
+// ErrRateLimited if that would exceed the fetch timeout. Cache hits aren't
+// limited. Zero or less removes the limit.
+func (f *Fetcher) SetRateLimit(perSecond float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.limiter = newRateLimiter(perSecond, rateLimitBurst)
+}
+
+// SetCacheMaxEntries limits how many songs are cached, evicting the least
+// recently used first. Zero or less removes the limit.
+func (f *Fetcher) SetCacheMaxEntries(maxEntries int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cache.setMaxEntries(maxEntries)
+}
+
+// SetNegativeCacheTTL sets how long songs without lyrics are remembered.
+// Zero or less disables caching them.
+func (f *Fetcher) SetNegativeCacheTTL(ttl time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.missTTL = ttl
+}
+
+// SetLocalDB makes the fetcher look up lyrics in a local lrclib database
+// before asking lrclib.net, which is only asked about songs the database
+// doesn't have. Nil stops using the database.
+func (f *Fetcher) SetLocalDB(db *LocalDB) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.localDB = db
+}
+
+// cacheDurationBucket is the precision of the track length in cache keys, so
+// players reporting slightly different lengths share an entry
+const cacheDurationBucket = 5 * time.Second
+
+// getCacheKey generates a cache key from artist and title, plus the album and
+// length when known so same-named tracks from different releases don't collide
+func (f *Fetcher) getCacheKey(artist, title, album string, duration time.Duration) string {
+	key := fmt.Sprintf("%s|||%s", artist, title)
+	if album != "" {
+		key += "|||" + album
+	}
+	if duration > 0 {
+		key += fmt.Sprintf("|||%d", duration.Round(cacheDurationBucket)/cacheDurationBucket)
+	}
+	return key
 }
 
 // FetchLyrics fetches synced lyrics for a song
-// Returns cached lyrics if available, otherwise fetches from source
-func (f *Fetcher) FetchLyrics(artist, title string) (*SyncedLyrics, error) {
-	cacheKey := f.getCacheKey(artist, title)
+// Returns cached lyrics if available, otherwise fetches from source.
+// A non-zero duration is used to pick the right version of the track.
+// The album and duration, when known, keep same-named tracks apart in the cache.
+// The lookup gives up once the fetch timeout passes, returning an error
+// that matches context.DeadlineExceeded.
+func (f *Fetcher) FetchLyrics(artist, title, album string, duration time.Duration) (*SyncedLyrics, error) {
+	cacheKey := f.getCacheKey(artist, title, album, duration)
 
-	// Check cache first
-	f.mu.RLock()
-	if lyrics, exists := f.cache[cacheKey]; exists {
-		f.mu.RUnlock()
-		return lyrics, nil
+	// Check cache first. A hit updates the recency order, so it needs the
+	// write lock.
+	f.mu.Lock()
+	noCache := f.noCache
+	if !noCache {
+		if lyrics, err, exists := f.cache.get(cacheKey, time.Now()); exists {
+			f.mu.Unlock()
+			return lyrics, err
+		}
 	}
-	f.mu.RUnlock()
+	f.mu.Unlock()
+
+	ctx, cancel := f.fetchContext()
+	defer cancel()
 
 	// Fetch lyrics from source
-	lyrics, err := f.fetchFromSource(artist, title)
+	lyrics, err := f.fetchFromSource(ctx, artist, title, duration, !noCache)
+	if errors.Is(err, ErrLyricsNotFound) || errors.Is(err, ErrNoSyncedLyrics) {
+		// Remember songs without lyrics so replays don't query the API again
+		f.mu.Lock()
+		if f.missTTL > 0 && !noCache {
+			f.cache.addMiss(cacheKey, err, time.Now().Add(f.missTTL))
+		}
+		f.mu.Unlock()
+		return nil, err
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	// Cache the result
-	f.mu.Lock()
-	f.cache[cacheKey] = lyrics
-	f.mu.Unlock()
+	if !noCache {
+		f.mu.Lock()
+		f.cache.add(cacheKey, lyrics)
+		f.mu.Unlock()
+	}
 
 	return lyrics, nil
 }
 
+// HasLyrics reports whether lyrics exist for a song and whether they are
+// synced, e.g. to check a track before it plays. lrclib has no cheaper
+// lookup, so it goes through the cache like FetchLyrics: a later fetch of
+// the song reuses the result, and repeated checks don't query the API.
+// Instrumental tracks count as found but not synced. Failed lookups report
+// nothing found and aren't cached.
+func (f *Fetcher) HasLyrics(artist, title string) (found, synced bool) {
+	lyrics, err := f.FetchLyrics(artist, title, "", 0)
+	if err != nil {
+		return false, false
+	}
+	return true, len(lyrics.Lines) > 0
+}
+
+// FetchLyricsBypassCache fetches a song's lyrics straight from the source,
+// neither reading nor updating the cache. Along with the parsed lyrics it
+// returns the raw LRC text as received, or the plain lyrics if the source
+// has no synced version, which helps to debug badly matched lyrics.
+// Unlike FetchLyrics, it only asks lrclib.net, skipping the local database,
+// and matches by artist and title alone, so there is no duration to retry
+// without.
+func (f *Fetcher) FetchLyricsBypassCache(artist, title string) (*SyncedLyrics, string, error) {
+	ctx, cancel := f.fetchContext()
+	defer cancel()
+
+	lrcResponse, err := f.fetchFromLRCLib(ctx, artist, title, 0, false)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch lyrics: %w", err)
+	}
+
+	var raw string
+	if lrcResponse.SyncedLyrics != nil && *lrcResponse.SyncedLyrics != "" {
+		raw = *lrcResponse.SyncedLyrics
+	} else if lrcResponse.PlainLyrics != nil {
+		raw = *lrcResponse.PlainLyrics
+	}
+
+	lyrics, err := lyricsFromResponse(lrcResponse)
+	if err != nil {
+		return nil, raw, err
+	}
+	return lyrics, raw, nil
+}
+
+// fetchContext returns a context that expires after the fetch timeout
+func (f *Fetcher) fetchContext() (context.Context, context.CancelFunc) {
+	f.mu.RLock()
+	fetchTimeout := f.fetchTimeout
+	f.mu.RUnlock()
+
+	if fetchTimeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), fetchTimeout)
+}
+
+// waitForRequest blocks until the rate limit allows another API request
+func (f *Fetcher) waitForRequest(ctx context.Context) error {
+	f.mu.RLock()
+	limiter := f.limiter
+	f.mu.RUnlock()
+	return limiter.wait(ctx)
+}
+
+// Ping checks that lrclib.net is reachable and answering API requests
+func (f *Fetcher) Ping() error {
+	ctx, cancel := f.fetchContext()
+	defer cancel()
+
+	params := url.Values{}
+	params.Add("q", "ping")
+	requestURL := fmt.Sprintf("https://lrclib.net/api/search?%s", params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+
+	if err := f.waitForRequest(ctx); err != nil {
+		return err
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("http request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &FetchError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return nil
+}
+
 // fetchFromSource fetches lyrics from an external source
-// Currently uses lrclib.net API as the primary source
-func (f *Fetcher) fetchFromSource(artist, title string) (*SyncedLyrics, error) {
+// Currently uses lrclib.net API as the primary source, after the local
+// database if one is set
+// With revalidate set, requests are made conditional on earlier responses.
+func (f *Fetcher) fetchFromSource(ctx context.Context, artist, title string, duration time.Duration, revalidate bool) (*SyncedLyrics, error) {
+	f.mu.RLock()
+	localDB := f.localDB
+	f.mu.RUnlock()
+
+	// A local database answers without the network, if it has the song
+	if localDB != nil {
+		lrcResponse, err := localDB.lookup(ctx, artist, title, duration)
+		if err == nil {
+			return lyricsFromResponse(lrcResponse)
+		}
+		if !errors.Is(err, ErrLyricsNotFound) {
+			debuglog.Printf("local lyrics database lookup failed: %v", err)
+		}
+	}
+
 	// Try lrclib.net API
-	lrcContent, err := f.fetchFromLRCLib(artist, title)
+	lrcResponse, err := f.fetchFromLRCLib(ctx, artist, title, duration, revalidate)
+	if errors.Is(err, ErrLyricsNotFound) && duration > 0 {
+		// The player's duration may not match lrclib's exactly, retry without it
+		lrcResponse, err = f.fetchFromLRCLib(ctx, artist, title, 0, revalidate)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch lyrics: %w", err)
 	}
 
-	// Parse the LRC content
-	lyrics, err := ParseLRC(lrcContent)
+	return lyricsFromResponse(lrcResponse)
+}
+
+// lyricsFromResponse converts an lrclib record into SyncedLyrics
+func lyricsFromResponse(lrcResponse *LRCLibResponse) (*SyncedLyrics, error) {
+	// Instrumental tracks have no lyrics, but that's a valid result worth caching
+	if lrcResponse.Instrumental {
+		return &SyncedLyrics{Instrumental: true}, nil
+	}
+
+	var plain string
+	if lrcResponse.PlainLyrics != nil {
+		plain = *lrcResponse.PlainLyrics
+	}
+
+	// Fall back to plain lyrics if no synced version exists
+	if lrcResponse.SyncedLyrics == nil || *lrcResponse.SyncedLyrics == "" {
+		return plainLyrics(plain)
+	}
+
+	// Parse the LRC content. Some synced versions hold nothing but ID tags,
+	// in which case the plain lyrics are still worth showing.
+	lyrics, err := ParseLRC(*lrcResponse.SyncedLyrics)
 	if err != nil {
+		if plain != "" {
+			debuglog.Printf("unusable synced lyrics (%v), using plain lyrics", err)
+			return plainLyrics(plain)
+		}
 		return nil, fmt.Errorf("failed to parse lyrics: %w", err)
 	}
+	lyrics.Plain = plain
 
 	return lyrics, nil
 }
 
+// plainLyrics returns unsynced lyrics, or ErrNoSyncedLyrics if there is no
+// text
+func plainLyrics(plain string) (*SyncedLyrics, error) {
+	if plain == "" {
+		return nil, ErrNoSyncedLyrics
+	}
+	return &SyncedLyrics{Plain: plain}, nil
+}
+
 // LRCLibResponse represents the JSON response from lrclib.net API
 type LRCLibResponse struct {
-	SyncedLyrics   *string `json:"syncedLyrics"`
-	PlainLyrics    *string `json:"plainLyrics"`
-	TrackName      string  `json:"trackName"`
-	ArtistName     string  `json:"artistName"`
+	ID           int     `json:"id"`
+	SyncedLyrics *string `json:"syncedLyrics"`
+	PlainLyrics  *string `json:"plainLyrics"`
+	TrackName    string  `json:"trackName"`
+	ArtistName   string  `json:"artistName"`
+	AlbumName    string  `json:"albumName"`
+	Duration     float64 `json:"duration"` // Track length in seconds
+	Instrumental bool    `json:"instrumental"`
 }
 
-// fetchFromLRCLib fetches lyrics from lrclib.net
-func (f *Fetcher) fetchFromLRCLib(artist, title string) (string, error) {
+// fetchFromLRCLib fetches lyrics from lrclib.net, matching the duration too
+// if it is non-zero. With revalidate set, the ETag and Last-Modified of an
+// earlier response for the same request are sent along, and a 304 Not
+// Modified reuses that response.
+func (f *Fetcher) fetchFromLRCLib(ctx context.Context, artist, title string, duration time.Duration, revalidate bool) (*LRCLibResponse, error) {
 	baseURL := "https://lrclib.net/api/get"
 
 	// Build query parameters
 	params := url.Values{}
 	params.Add("artist_name", artist)
 	params.Add("track_name", title)
+	if duration > 0 {
+		params.Add("duration", strconv.Itoa(int(duration.Round(time.Second).Seconds())))
+	}
 
 	requestURL := fmt.Sprintf("%s?%s", baseURL, params.Encode())
 
-	resp, err := f.client.Get(requestURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+
+	var stored *validatedResponse
+	if revalidate {
+		f.mu.Lock()
+		stored = f.validators.apply(req)
+		f.mu.Unlock()
+	}
+
+	if err := f.waitForRequest(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := f.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("http request failed: %w", err)
+		return nil, fmt.Errorf("http request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && stored != nil {
+		debuglog.Printf("lyrics for %s - %s not modified, reusing them", artist, title)
+		f.mu.Lock()
+		f.cache.stats.Revalidated++
+		f.mu.Unlock()
+		return stored.response, nil
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrLyricsNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &FetchError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	// Parse JSON response
 	var lrcResponse LRCLibResponse
 	if err := json.Unmarshal(body, &lrcResponse); err != nil {
-		return "", fmt.Errorf("failed to parse JSON response: %w", err)
+		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
 	}
 
-	// Check if syncedLyrics is available
-	if lrcResponse.SyncedLyrics == nil || *lrcResponse.SyncedLyrics == "" {
-		return "", fmt.Errorf("no synced lyrics available for this song")
+	if revalidate {
+		f.mu.Lock()
+		f.validators.store(requestURL, resp.Header, &lrcResponse)
+		f.mu.Unlock()
 	}
 
-	return *lrcResponse.SyncedLyrics, nil
+	return &lrcResponse, nil
+}
+
+// Invalidate removes a song from the cache so the next fetch goes to the source
+func (f *Fetcher) Invalidate(artist, title, album string, duration time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cache.remove(f.getCacheKey(artist, title, album, duration))
+}
+
+// ClearNegativeCache forgets which songs had no lyrics, so they are looked
+// up again on their next play
+func (f *Fetcher) ClearNegativeCache() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cache.removeMisses()
 }
 
-// ClearCache clears the lyrics cache
+// ClearCache clears the lyrics cache, including songs without lyrics and the
+// responses kept for conditional requests. The statistics are kept.
 func (f *Fetcher) ClearCache() {
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	f.cache = make(map[string]*SyncedLyrics)
+	stats := f.cache.stats
+	f.cache = newLyricsCache(f.cache.maxEntries)
+	f.cache.stats = stats
+	f.validators = newValidatorStore()
+}
+
+// Stats returns the cache's hit, miss and eviction counts since the fetcher
+// was created, along with its current size
+func (f *Fetcher) Stats() CacheStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stats := f.cache.stats
+	stats.Entries = f.cache.order.Len()
+	return stats
 }
@@ -0,0 +1,111 @@
+package lyrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// localDBDurationSlack is how far a track's length in the database may be
+// from the player's and still count as the same version, as in lrclib's API
+const localDBDurationSlack = 2 * time.Second
+
+// LocalDB looks up lyrics in a local copy of lrclib's SQLite database dump,
+// for running without network access. It queries the database with the
+// sqlite3 command-line tool, which must be installed (version 3.33 or
+// later, for JSON output).
+type LocalDB struct {
+	path    string
+	sqlite3 string
+}
+
+// OpenLocalDB checks that the database at path exists and that sqlite3 is
+// installed
+func OpenLocalDB(path string) (*LocalDB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("lyrics database not found: %w", err)
+	}
+	sqlite3, err := exec.LookPath("sqlite3")
+	if err != nil {
+		return nil, fmt.Errorf("sqlite3 not found: %w", err)
+	}
+	return &LocalDB{path: path, sqlite3: sqlite3}, nil
+}
+
+// localDBRow is a result row of LocalDB.lookup
+type localDBRow struct {
+	SyncedLyrics *string `json:"synced_lyrics"`
+	PlainLyrics  *string `json:"plain_lyrics"`
+	Instrumental int     `json:"instrumental"`
+	Name         string  `json:"name"`
+	ArtistName   string  `json:"artist_name"`
+	AlbumName    string  `json:"album_name"`
+	Duration     float64 `json:"duration"`
+	ID           int     `json:"id"`
+}
+
+// lookup finds a song's lyrics by artist and title, returning
+// ErrLyricsNotFound if the database has none. With a non-zero duration, a
+// version of about that length is preferred; otherwise synced lyrics are
+// preferred over plain ones.
+func (db *LocalDB) lookup(ctx context.Context, artist, title string, duration time.Duration) (*LRCLibResponse, error) {
+	order := "has_synced DESC"
+	if duration > 0 {
+		seconds := duration.Seconds()
+		order = fmt.Sprintf("abs(t.duration - %[1]f) <= %[2]f DESC, has_synced DESC, abs(t.duration - %[1]f)",
+			seconds, localDBDurationSlack.Seconds())
+	}
+	// The dump stores lowercased names for lookups
+	query := fmt.Sprintf(`SELECT l.synced_lyrics, l.plain_lyrics, l.instrumental,
+		t.name, t.artist_name, t.album_name, t.duration, t.id,
+		coalesce(l.synced_lyrics, '') != '' AS has_synced
+	FROM tracks t JOIN lyrics l ON l.id = t.last_lyrics_id
+	WHERE t.name_lower = %s AND t.artist_name_lower = %s
+	ORDER BY %s LIMIT 1`,
+		sqlQuote(strings.ToLower(strings.TrimSpace(title))),
+		sqlQuote(strings.ToLower(strings.TrimSpace(artist))),
+		order)
+
+	out, err := exec.CommandContext(ctx, db.sqlite3, "-readonly", "-json", db.path, query).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("sqlite3 failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("sqlite3 failed: %w", err)
+	}
+
+	// No rows print nothing rather than an empty array
+	var rows []localDBRow
+	if len(strings.TrimSpace(string(out))) > 0 {
+		if err := json.Unmarshal(out, &rows); err != nil {
+			return nil, fmt.Errorf("failed to parse sqlite3 output: %w", err)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, ErrLyricsNotFound
+	}
+
+	row := rows[0]
+	return &LRCLibResponse{
+		ID:           row.ID,
+		SyncedLyrics: row.SyncedLyrics,
+		PlainLyrics:  row.PlainLyrics,
+		TrackName:    row.Name,
+		ArtistName:   row.ArtistName,
+		AlbumName:    row.AlbumName,
+		Duration:     row.Duration,
+		Instrumental: row.Instrumental != 0,
+	}, nil
+}
+
+// sqlQuote quotes s as an SQL string literal
+func sqlQuote(s string) string {
+	s = strings.ReplaceAll(s, "\x00", "")
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
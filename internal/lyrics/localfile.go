@@ -0,0 +1,109 @@
+package lyrics
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// maxFileNameDistance is the largest edit distance, per 10 characters of
+// the normalized song name, at which a file name still matches
+const maxFileNameDistance = 1
+
+// lyricsFileExts are the extensions of local lyrics files, in order of
+// preference
+var lyricsFileExts = []string{".lrc", ".srt"}
+
+// findLyricsFile returns the path of the LRC or SRT file for a song in dir.
+// The "Artist - Title.lrc" and "Artist - Title.srt" names are tried first;
+// failing that, the file whose name is closest after ignoring case,
+// punctuation and spacing is used, so "artist-title.lrc" or
+// "Artist – Title.srt" are found too.
+func findLyricsFile(dir, artist, title string) (string, error) {
+	base := strings.TrimSuffix(LRCFileName(artist, title), ".lrc")
+	for _, ext := range lyricsFileExts {
+		path := filepath.Join(dir, base+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	want := normalizeFileName(artist + " " + title)
+	maxDistance := maxFileNameDistance * (len([]rune(want))/10 + 1)
+
+	best, bestDistance := "", maxDistance+1
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !isLyricsFile(name) {
+			continue
+		}
+
+		distance := editDistance(want, normalizeFileName(strings.TrimSuffix(name, filepath.Ext(name))))
+		if distance < bestDistance {
+			best, bestDistance = name, distance
+		}
+	}
+
+	if best == "" {
+		return "", fmt.Errorf("no LRC or SRT file for %s - %s in %s: %w", artist, title, dir, os.ErrNotExist)
+	}
+	return filepath.Join(dir, best), nil
+}
+
+// isLyricsFile reports whether name has one of the lyricsFileExts
+func isLyricsFile(name string) bool {
+	ext := filepath.Ext(name)
+	for _, want := range lyricsFileExts {
+		if strings.EqualFold(ext, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeFileName lowercases s and turns each run of punctuation and
+// spaces into a single space
+func normalizeFileName(s string) string {
+	var b strings.Builder
+	space := true
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+		} else if !space {
+			b.WriteRune(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// editDistance returns the Levenshtein distance between a and b in runes
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -2,33 +2,103 @@ package lyrics
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/debuglog"
 )
 
 // LyricLine represents a single line of lyrics with its timestamp
 type LyricLine struct {
-	Time time.Duration
-	Text string
+	Time        time.Duration
+	Text        string
+	Raw         string // Text before annotations were stripped, if they were
+	Romanized   string // Latin-script version of Text, if romanization was applied
+	Translation string // Translated text, if a translation was aligned
 }
 
 // SyncedLyrics contains all lyric lines sorted by timestamp
 type SyncedLyrics struct {
 	Lines []LyricLine
+	Plain string // Unsynced lyrics text, if provided by the source
+
+	// Instrumental is set for tracks the source marks as having no vocals
+	Instrumental bool
+
+	// Metadata from LRC ID tags such as [ar:Artist], keyed by lowercase tag name
+	Metadata map[string]string
+	Artist   string        // [ar:] tag
+	Title    string        // [ti:] tag
+	Album    string        // [al:] tag
+	Length   time.Duration // [length:] tag, zero if absent or invalid
+}
+
+// Limits on LRC content, so a broken or hostile file can't exhaust memory
+const (
+	// DefaultMaxLRCSize is the largest LRC content parsed, in bytes
+	DefaultMaxLRCSize = 4 << 20
+	// DefaultMaxLRCLines is the most timed lines parsed
+	DefaultMaxLRCLines = 20000
+	// maxLRCLineLength is the longest single line read. Longer lines are
+	// reported rather than failing with bufio.Scanner's generic error.
+	maxLRCLineLength = 256 << 10
+)
+
+// ParseOptions controls optional LRC parsing behaviour
+type ParseOptions struct {
+	// KeepMarkers keeps timestamps without lyric text, such as an [00:00]
+	// intro marker, as lines with empty text instead of dropping them
+	KeepMarkers bool
+
+	// MaxSize is the largest content parsed, in bytes. Zero uses
+	// DefaultMaxLRCSize; negative removes the limit.
+	MaxSize int
+
+	// MaxLines is the most timed lines parsed. Zero uses
+	// DefaultMaxLRCLines; negative removes the limit.
+	MaxLines int
+}
+
+// parseLimit returns a limit option with its default applied, or -1 for none
+func parseLimit(value, def int) int {
+	if value == 0 {
+		return def
+	}
+	return max(value, -1)
 }
 
 // ParseLRC parses LRC format lyrics into structured data
 // LRC format: [mm:ss.xx]Lyric text
+// Single-digit fields, millisecond fractions and colon-separated fractions
+// such as [m:s], [mm:ss.xxx] and [mm:ss:xx] are also accepted, wherever the
+// timestamp appears in the line.
 func ParseLRC(lrcContent string) (*SyncedLyrics, error) {
-	// Regex to match LRC timestamp format [mm:ss.xx] or [mm:ss]
-	timeRegex := regexp.MustCompile(`\[(\d{2}):(\d{2})\.?(\d{2})?\]`)
+	return ParseLRCWithOptions(lrcContent, ParseOptions{})
+}
+
+// ParseLRCWithOptions parses LRC format lyrics like ParseLRC, with optional
+// behaviour controlled by opts
+func ParseLRCWithOptions(lrcContent string, opts ParseOptions) (*SyncedLyrics, error) {
+	// Regex to match LRC timestamp formats like [mm:ss.xx], [mm:ss] or [m:ss:xx]
+	timeRegex := regexp.MustCompile(`\[(\d{1,3}):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+
+	maxSize := parseLimit(opts.MaxSize, DefaultMaxLRCSize)
+	if maxSize >= 0 && len(lrcContent) > maxSize {
+		return nil, fmt.Errorf("%w: LRC content is %d bytes, the limit is %d", ErrLyricsTooLarge, len(lrcContent), maxSize)
+	}
+	maxLines := parseLimit(opts.MaxLines, DefaultMaxLRCLines)
 
 	scanner := bufio.NewScanner(strings.NewReader(lrcContent))
+	scanner.Buffer(nil, maxLRCLineLength)
 	var lines []LyricLine
+	seen := make(map[LyricLine]bool)
+	metadata := make(map[string]string)
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -36,15 +106,22 @@ func ParseLRC(lrcContent string) (*SyncedLyrics, error) {
 		// Find all timestamp matches in the line
 		matches := timeRegex.FindAllStringSubmatch(line, -1)
 		if len(matches) == 0 {
+			// Lines without timestamps may be ID tags like [ar:Artist]
+			trimmed := strings.TrimSpace(line)
+			if tag := metadataRegex.FindStringSubmatch(trimmed); tag != nil {
+				metadata[strings.ToLower(tag[1])] = strings.TrimSpace(tag[2])
+			} else if strings.HasPrefix(trimmed, "[") {
+				debuglog.Printf("skipping unrecognized LRC line: %q", trimmed)
+			}
 			continue
 		}
 
-		// Extract the lyric text (everything after the last timestamp)
+		// Extract the lyric text, removing timestamps wherever they appear
 		text := timeRegex.ReplaceAllString(line, "")
 		text = strings.TrimSpace(text)
 
-		// Skip empty lyrics (metadata lines like [ar:Artist])
-		if text == "" {
+		// Skip timestamps without lyric text unless markers are wanted
+		if text == "" && !opts.KeepMarkers {
 			continue
 		}
 
@@ -52,27 +129,43 @@ func ParseLRC(lrcContent string) (*SyncedLyrics, error) {
 		for _, match := range matches {
 			minutes, _ := strconv.Atoi(match[1])
 			seconds, _ := strconv.Atoi(match[2])
-			var centiseconds int
+			var milliseconds int
 			if match[3] != "" {
-				centiseconds, _ = strconv.Atoi(match[3])
+				// Scale the fraction by its digit count: .5, .50 and .500 are equal
+				fraction := match[3] + strings.Repeat("0", 3-len(match[3]))
+				milliseconds, _ = strconv.Atoi(fraction)
 			}
 
 			timestamp := time.Duration(minutes)*time.Minute +
 				time.Duration(seconds)*time.Second +
-				time.Duration(centiseconds)*10*time.Millisecond
+				time.Duration(milliseconds)*time.Millisecond
 
-			lines = append(lines, LyricLine{
+			lyricLine := LyricLine{
 				Time: timestamp,
 				Text: text,
-			})
+			}
+
+			// Skip exact duplicates of a line we already have
+			if seen[lyricLine] {
+				continue
+			}
+			seen[lyricLine] = true
+
+			if maxLines >= 0 && len(lines) >= maxLines {
+				return nil, fmt.Errorf("%w: LRC content has more than %d timed lines", ErrLyricsTooLarge, maxLines)
+			}
+			lines = append(lines, lyricLine)
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("%w: LRC content has a line longer than %d bytes", ErrLyricsTooLarge, maxLRCLineLength)
+		}
 		return nil, fmt.Errorf("error reading LRC content: %w", err)
 	}
 
-	if len(lines) == 0 {
+	if !hasText(lines) {
 		return nil, fmt.Errorf("no valid lyrics found in LRC content")
 	}
 
@@ -81,7 +174,52 @@ func ParseLRC(lrcContent string) (*SyncedLyrics, error) {
 		return lines[i].Time < lines[j].Time
 	})
 
-	return &SyncedLyrics{Lines: lines}, nil
+	return newSyncedLyrics(lines, metadata), nil
+}
+
+// hasText reports whether any line has lyric text, as opposed to only
+// empty markers
+func hasText(lines []LyricLine) bool {
+	for _, line := range lines {
+		if line.Text != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// metadataRegex matches LRC ID tags like [ar:Artist] or [length:03:45]
+var metadataRegex = regexp.MustCompile(`^\[([a-zA-Z]+):(.*)\]$`)
+
+// newSyncedLyrics builds SyncedLyrics from parsed lines and ID tags
+func newSyncedLyrics(lines []LyricLine, metadata map[string]string) *SyncedLyrics {
+	sl := &SyncedLyrics{
+		Lines:    lines,
+		Metadata: metadata,
+		Artist:   metadata["ar"],
+		Title:    metadata["ti"],
+		Album:    metadata["al"],
+	}
+
+	// Length is given as mm:ss, optionally with a fractional part
+	if length, ok := metadata["length"]; ok {
+		var minutes int
+		var seconds float64
+		if _, err := fmt.Sscanf(length, "%d:%f", &minutes, &seconds); err == nil {
+			sl.Length = time.Duration(minutes)*time.Minute + time.Duration(seconds*float64(time.Second))
+		}
+	}
+
+	return sl
+}
+
+// Clone returns a copy of the lyrics whose lines can be changed without
+// affecting the original. Metadata is shared, as nothing changes it after
+// parsing.
+func (sl *SyncedLyrics) Clone() *SyncedLyrics {
+	clone := *sl
+	clone.Lines = slices.Clone(sl.Lines)
+	return &clone
 }
 
 // GetLineAtTime returns the lyric line that should be displayed at the given time
@@ -102,3 +240,70 @@ func (sl *SyncedLyrics) GetLineAtTime(position time.Duration) *LyricLine {
 
 	return currentLine
 }
+
+// NextLineTime returns the timestamp of the first line after the given time.
+// Returns false if there are no more lines.
+func (sl *SyncedLyrics) NextLineTime(position time.Duration) (time.Duration, bool) {
+	for i := range sl.Lines {
+		if sl.Lines[i].Time > position {
+			return sl.Lines[i].Time, true
+		}
+	}
+	return 0, false
+}
+
+// GetContext returns the lines surrounding the one displayed at the given
+// time: up to before lines preceding it and up to after lines following it.
+// The returned index is the current line's position within the slice, or -1
+// if the position is before the first line.
+func (sl *SyncedLyrics) GetContext(position time.Duration, before, after int) ([]LyricLine, int) {
+	if len(sl.Lines) == 0 {
+		return nil, -1
+	}
+
+	// Find the index of the current line
+	current := -1
+	for i := range sl.Lines {
+		if sl.Lines[i].Time <= position {
+			current = i
+		} else {
+			break
+		}
+	}
+
+	start := current - before
+	if start < 0 {
+		start = 0
+	}
+	end := current + after + 1
+	if end > len(sl.Lines) {
+		end = len(sl.Lines)
+	}
+
+	if current < 0 {
+		return sl.Lines[start:end], -1
+	}
+	return sl.Lines[start:end], current - start
+}
+
+// FullText returns the complete lyrics as plain text, one line per lyric.
+// Each line is included once, at its first occurrence, so choruses and
+// lines with several timestamps aren't repeated, and empty marker lines are
+// left out.
+// Falls back to the plain lyrics when no synced lines are available.
+func (sl *SyncedLyrics) FullText() string {
+	if len(sl.Lines) == 0 {
+		return strings.TrimSpace(sl.Plain)
+	}
+
+	var texts []string
+	seen := make(map[string]bool)
+	for _, line := range sl.Lines {
+		if line.Text == "" || seen[line.Text] {
+			continue
+		}
+		seen[line.Text] = true
+		texts = append(texts, line.Text)
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,82 @@
+package lyrics
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Outbound request limits
+const (
+	// DefaultRateLimit is how many lyrics API requests may be sent per second
+	DefaultRateLimit = 2.0
+	// rateLimitBurst is how many requests may be sent at once before the
+	// rate applies, e.g. a lookup and its retry without the duration
+	rateLimitBurst = 3
+)
+
+// rateLimiter is a token bucket limiting how often requests are sent. A nil
+// limiter allows every request.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // Tokens added per second
+	burst  float64 // Most tokens the bucket holds
+	tokens float64 // Negative while requests are queued
+	last   time.Time
+}
+
+// newRateLimiter creates a limiter allowing rate requests per second, or
+// returns nil if rate is zero or less
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until a request may be sent. It returns ErrRateLimited at
+// once if that would be after ctx expires, and ctx's error if ctx is
+// cancelled while waiting.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	var delay time.Duration
+	if l.tokens < 1 {
+		delay = time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	}
+	if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+		l.mu.Unlock()
+		return ErrRateLimited
+	}
+	l.tokens--
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Hand the token back for the requests queued behind this one
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,64 @@
+package lyrics
+
+import "net/http"
+
+// maxValidators is how many lrclib responses are kept for conditional
+// requests. They outlive the lyrics cache entries, so reloading a song's
+// lyrics can be answered with 304 Not Modified.
+const maxValidators = 256
+
+// validatedResponse is an lrclib response with the validators it was sent
+// with
+type validatedResponse struct {
+	etag         string
+	lastModified string
+	response     *LRCLibResponse
+}
+
+// validatorStore remembers the ETag and Last-Modified of lrclib responses by
+// request URL, dropping the oldest beyond maxValidators. It is not safe for
+// concurrent use; Fetcher guards it with its mutex.
+type validatorStore struct {
+	entries map[string]*validatedResponse
+	order   []string // Oldest first
+}
+
+// newValidatorStore creates an empty store
+func newValidatorStore() *validatorStore {
+	return &validatorStore{entries: make(map[string]*validatedResponse)}
+}
+
+// apply adds conditional headers to req if a response for its URL is stored,
+// returning that response
+func (s *validatorStore) apply(req *http.Request) *validatedResponse {
+	stored, ok := s.entries[req.URL.String()]
+	if !ok {
+		return nil
+	}
+
+	if stored.etag != "" {
+		req.Header.Set("If-None-Match", stored.etag)
+	}
+	if stored.lastModified != "" {
+		req.Header.Set("If-Modified-Since", stored.lastModified)
+	}
+	return stored
+}
+
+// store records a response for requestURL if the server sent validators
+func (s *validatorStore) store(requestURL string, header http.Header, response *LRCLibResponse) {
+	etag, lastModified := header.Get("ETag"), header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return
+	}
+
+	if _, ok := s.entries[requestURL]; !ok {
+		s.order = append(s.order, requestURL)
+	}
+	s.entries[requestURL] = &validatedResponse{etag: etag, lastModified: lastModified, response: response}
+
+	for len(s.order) > maxValidators {
+		delete(s.entries, s.order[0])
+		s.order = s.order[1:]
+	}
+}
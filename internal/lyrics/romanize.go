@@ -0,0 +1,169 @@
+package lyrics
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Transliterator converts text into Latin script.
+// Text that needs no conversion is returned unchanged.
+type Transliterator func(text string) (string, error)
+
+// Romanize fills in the Romanized field of every line whose transliteration
+// differs from the original text. The lines are changed in place, so lyrics
+// shared with the fetcher's cache must be cloned first.
+func (sl *SyncedLyrics) Romanize(transliterate Transliterator) error {
+	for i := range sl.Lines {
+		romanized, err := transliterate(sl.Lines[i].Text)
+		if err != nil {
+			return err
+		}
+		if romanized != sl.Lines[i].Text {
+			sl.Lines[i].Romanized = romanized
+		}
+	}
+	return nil
+}
+
+// DefaultTransliterator romanizes Korean Hangul (Revised Romanization) and
+// Japanese kana (Hepburn). Characters it doesn't know, including kanji and
+// hanzi, are passed through unchanged.
+func DefaultTransliterator(text string) (string, error) {
+	runes := []rune(text)
+	var out strings.Builder
+	geminate := false
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case r >= hangulBase && r <= hangulLast:
+			out.WriteString(romanizeHangul(r))
+
+		case r == 'っ' || r == 'ッ':
+			// Small tsu doubles the next consonant
+			geminate = true
+
+		case r == 'ー':
+			// Long vowel mark repeats the previous vowel
+			if last := lastVowel(out.String()); last != 0 {
+				out.WriteRune(last)
+			}
+
+		case isKana(r):
+			romaji := kanaRomaji[toHiragana(r)]
+
+			// Combine with a following small kana, e.g. きゃ -> kya, ファ -> fa
+			if i+1 < len(runes) {
+				if small, ok := smallKana[toHiragana(runes[i+1])]; ok && romaji != "" {
+					romaji = combineKana(romaji, small)
+					i++
+				}
+			}
+
+			if geminate && romaji != "" {
+				if strings.HasPrefix(romaji, "ch") {
+					out.WriteByte('t')
+				} else if !strings.ContainsRune("aiueon", rune(romaji[0])) {
+					out.WriteByte(romaji[0])
+				}
+				geminate = false
+			}
+			out.WriteString(romaji)
+
+		default:
+			geminate = false
+			out.WriteRune(r)
+		}
+	}
+
+	return out.String(), nil
+}
+
+// Hangul syllable block layout
+const (
+	hangulBase  = 0xAC00
+	hangulLast  = 0xD7A3
+	hangulVowel = 21
+	hangulFinal = 28
+)
+
+var (
+	hangulInitials = []string{"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s", "ss", "", "j", "jj", "ch", "k", "t", "p", "h"}
+	hangulMedials  = []string{"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa", "wae", "oe", "yo", "u", "wo", "we", "wi", "yu", "eu", "ui", "i"}
+	hangulFinals   = []string{"", "k", "k", "k", "n", "n", "n", "t", "l", "k", "m", "l", "l", "l", "p", "l", "m", "p", "p", "t", "t", "ng", "t", "t", "k", "t", "p", "t"}
+)
+
+// romanizeHangul romanizes a single precomposed Hangul syllable
+func romanizeHangul(r rune) string {
+	code := int(r - hangulBase)
+	initial := code / (hangulVowel * hangulFinal)
+	medial := code % (hangulVowel * hangulFinal) / hangulFinal
+	final := code % hangulFinal
+	return hangulInitials[initial] + hangulMedials[medial] + hangulFinals[final]
+}
+
+// isKana reports whether r is a hiragana or katakana character
+func isKana(r rune) bool {
+	return unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r)
+}
+
+// toHiragana maps katakana to the equivalent hiragana
+func toHiragana(r rune) rune {
+	if r >= 'ァ' && r <= 'ヶ' {
+		return r - 0x60
+	}
+	return r
+}
+
+// combineKana merges a kana's romaji with a following small kana
+func combineKana(romaji, small string) string {
+	// Small vowels replace the vowel: ふぁ -> fa, てぃ -> ti
+	if len(small) == 1 {
+		return romaji[:len(romaji)-1] + small
+	}
+
+	// Small ya/yu/yo palatalize an i-row kana: きゃ -> kya, しゃ -> sha
+	stem := strings.TrimSuffix(romaji, "i")
+	if strings.HasSuffix(stem, "sh") || strings.HasSuffix(stem, "ch") || strings.HasSuffix(stem, "j") {
+		return stem + small[1:]
+	}
+	return stem + small
+}
+
+// lastVowel returns the last vowel written so far, or 0 if there is none
+func lastVowel(s string) rune {
+	for i := len(s) - 1; i >= 0; i-- {
+		if strings.IndexByte("aiueo", s[i]) >= 0 {
+			return rune(s[i])
+		}
+	}
+	return 0
+}
+
+// kanaRomaji maps hiragana to Hepburn romaji
+var kanaRomaji = map[rune]string{
+	'あ': "a", 'い': "i", 'う': "u", 'え': "e", 'お': "o",
+	'か': "ka", 'き': "ki", 'く': "ku", 'け': "ke", 'こ': "ko",
+	'が': "ga", 'ぎ': "gi", 'ぐ': "gu", 'げ': "ge", 'ご': "go",
+	'さ': "sa", 'し': "shi", 'す': "su", 'せ': "se", 'そ': "so",
+	'ざ': "za", 'じ': "ji", 'ず': "zu", 'ぜ': "ze", 'ぞ': "zo",
+	'た': "ta", 'ち': "chi", 'つ': "tsu", 'て': "te", 'と': "to",
+	'だ': "da", 'ぢ': "ji", 'づ': "zu", 'で': "de", 'ど': "do",
+	'な': "na", 'に': "ni", 'ぬ': "nu", 'ね': "ne", 'の': "no",
+	'は': "ha", 'ひ': "hi", 'ふ': "fu", 'へ': "he", 'ほ': "ho",
+	'ば': "ba", 'び': "bi", 'ぶ': "bu", 'べ': "be", 'ぼ': "bo",
+	'ぱ': "pa", 'ぴ': "pi", 'ぷ': "pu", 'ぺ': "pe", 'ぽ': "po",
+	'ま': "ma", 'み': "mi", 'む': "mu", 'め': "me", 'も': "mo",
+	'や': "ya", 'ゆ': "yu", 'よ': "yo",
+	'ら': "ra", 'り': "ri", 'る': "ru", 'れ': "re", 'ろ': "ro",
+	'わ': "wa", 'ゐ': "i", 'ゑ': "e", 'を': "o", 'ん': "n", 'ゔ': "vu",
+	'ぁ': "a", 'ぃ': "i", 'ぅ': "u", 'ぇ': "e", 'ぉ': "o",
+	'ゃ': "ya", 'ゅ': "yu", 'ょ': "yo", 'ゎ': "wa",
+}
+
+// smallKana maps the small kana that combine with the preceding kana
+var smallKana = map[rune]string{
+	'ぁ': "a", 'ぃ': "i", 'ぅ': "u", 'ぇ': "e", 'ぉ': "o",
+	'ゃ': "ya", 'ゅ': "yu", 'ょ': "yo",
+}
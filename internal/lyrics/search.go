@@ -0,0 +1,139 @@
+package lyrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// Candidate is one of several lyrics versions found for a song
+type Candidate struct {
+	ID       int
+	Artist   string
+	Title    string
+	Album    string
+	Duration time.Duration // Track length, zero if unknown
+	Synced   bool          // Whether the version has synced lyrics
+
+	response *LRCLibResponse
+}
+
+// Lyrics parses the candidate's lyrics
+func (c Candidate) Lyrics() (*SyncedLyrics, error) {
+	if c.response == nil {
+		return nil, fmt.Errorf("candidate has no lyrics")
+	}
+	return lyricsFromResponse(c.response)
+}
+
+// Search looks up every lyrics version available for a song and returns up
+// to limit of them, best first: synced versions come before plain ones, and
+// versions whose length is closest to duration before the rest
+func (f *Fetcher) Search(artist, title string, duration time.Duration, limit int) ([]Candidate, error) {
+	ctx, cancel := f.fetchContext()
+	defer cancel()
+
+	results, err := f.searchLRCLib(ctx, artist, title)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search lyrics: %w", err)
+	}
+
+	var candidates []Candidate
+	for i := range results {
+		result := &results[i]
+		synced := result.SyncedLyrics != nil && *result.SyncedLyrics != ""
+		plain := result.PlainLyrics != nil && *result.PlainLyrics != ""
+		if !synced && !plain && !result.Instrumental {
+			continue
+		}
+		candidates = append(candidates, Candidate{
+			ID:       result.ID,
+			Artist:   result.ArtistName,
+			Title:    result.TrackName,
+			Album:    result.AlbumName,
+			Duration: time.Duration(result.Duration * float64(time.Second)),
+			Synced:   synced,
+			response: result,
+		})
+	}
+
+	if len(candidates) == 0 {
+		return nil, ErrLyricsNotFound
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Synced != candidates[j].Synced {
+			return candidates[i].Synced
+		}
+		if duration > 0 {
+			return absDuration(candidates[i].Duration-duration) < absDuration(candidates[j].Duration-duration)
+		}
+		return false
+	})
+
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates, nil
+}
+
+// UseCandidate parses a candidate's lyrics and caches them for the song, so
+// later fetches return the chosen version while caching is enabled
+func (f *Fetcher) UseCandidate(artist, title, album string, duration time.Duration, candidate Candidate) (*SyncedLyrics, error) {
+	lyrics, err := candidate.Lyrics()
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	if !f.noCache {
+		f.cache.add(f.getCacheKey(artist, title, album, duration), lyrics)
+	}
+	f.mu.Unlock()
+
+	return lyrics, nil
+}
+
+// searchLRCLib queries lrclib.net's search endpoint
+func (f *Fetcher) searchLRCLib(ctx context.Context, artist, title string) ([]LRCLibResponse, error) {
+	params := url.Values{}
+	params.Add("artist_name", artist)
+	params.Add("track_name", title)
+
+	requestURL := fmt.Sprintf("https://lrclib.net/api/search?%s", params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+
+	if err := f.waitForRequest(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &FetchError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var results []LRCLibResponse
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,119 @@
+package lyrics
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// srtTimingRegex matches an SRT cue timing line like
+// "00:01:02,500 --> 00:01:05,000". A dot is accepted in place of the comma.
+var srtTimingRegex = regexp.MustCompile(`^(\d{1,2}):(\d{2}):(\d{2})[,.](\d{1,3})\s*-->`)
+
+// srtTagRegex matches the formatting tags SRT allows in cue text, such as
+// <i>, </b>, <font color="..."> and {\an8} positioning
+var srtTagRegex = regexp.MustCompile(`</?[a-zA-Z][^>]*>|\{\\[^}]*\}`)
+
+// ParseSRT parses SubRip subtitles into lyrics. Each cue becomes a line at
+// the cue's start time, with the cue's text lines joined by spaces. Cues
+// starting at the same time are merged into one line, since only one line
+// is current at a time; overlapping cues otherwise keep their own start.
+func ParseSRT(srtContent string) (*SyncedLyrics, error) {
+	content := strings.TrimPrefix(srtContent, "\ufeff")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	byTime := make(map[time.Duration][]string)
+	var times []time.Duration
+
+	// A cue is its number, its timing and its text up to the next cue.
+	// Cues are read from timing to timing, so a missing blank line or
+	// number between them doesn't matter.
+	var start time.Duration
+	var parts []string
+	inCue := false
+	addCue := func() {
+		if !inCue || len(parts) == 0 {
+			return
+		}
+		text := strings.Join(parts, " ")
+		if _, ok := byTime[start]; !ok {
+			times = append(times, start)
+		}
+		// Skip a cue repeated word for word at the same time
+		if !slices.Contains(byTime[start], text) {
+			byTime[start] = append(byTime[start], text)
+		}
+	}
+
+	rows := strings.Split(content, "\n")
+	for i, row := range rows {
+		row = strings.TrimSpace(row)
+
+		match := srtTimingRegex.FindStringSubmatch(row)
+		if match == nil {
+			// Skip the next cue's number
+			if _, err := strconv.Atoi(row); err == nil && i+1 < len(rows) && srtTimingRegex.MatchString(strings.TrimSpace(rows[i+1])) {
+				continue
+			}
+			if text := strings.TrimSpace(srtTagRegex.ReplaceAllString(row, "")); text != "" && inCue {
+				parts = append(parts, text)
+			}
+			continue
+		}
+
+		addCue()
+		hours, _ := strconv.Atoi(match[1])
+		minutes, _ := strconv.Atoi(match[2])
+		seconds, _ := strconv.Atoi(match[3])
+		fraction := match[4] + strings.Repeat("0", 3-len(match[4]))
+		milliseconds, _ := strconv.Atoi(fraction)
+		start = time.Duration(hours)*time.Hour +
+			time.Duration(minutes)*time.Minute +
+			time.Duration(seconds)*time.Second +
+			time.Duration(milliseconds)*time.Millisecond
+		parts = nil
+		inCue = true
+	}
+	addCue()
+
+	if len(times) == 0 {
+		return nil, fmt.Errorf("no valid lyrics found in SRT content")
+	}
+
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	lines := make([]LyricLine, len(times))
+	for i, t := range times {
+		lines[i] = LyricLine{Time: t, Text: strings.Join(byTime[t], " ")}
+	}
+
+	return newSyncedLyrics(lines, map[string]string{}), nil
+}
+
+// LoadLyricsFile reads a local lyrics file, parsing it as SRT if its name
+// ends in .srt and as LRC otherwise
+func LoadLyricsFile(path string) (*SyncedLyrics, error) {
+	// Check the size before reading the whole file into memory
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > DefaultMaxLRCSize {
+		return nil, fmt.Errorf("%w: %s is %d bytes, the limit is %d", ErrLyricsTooLarge, path, info.Size(), DefaultMaxLRCSize)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".srt") {
+		return ParseSRT(string(data))
+	}
+	return ParseLRC(string(data))
+}
@@ -0,0 +1,66 @@
+package lyrics
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxTranslationSkew is the largest timestamp difference at which a
+// translated line is still matched to an original line
+const maxTranslationSkew = 3 * time.Second
+
+// AlignTranslation sets the Translation of each line to the text of the
+// translated line with the nearest timestamp. Lines without a translated
+// line close enough in time are left untranslated. The lines are changed in
+// place, so lyrics shared with the fetcher's cache must be cloned first.
+func (sl *SyncedLyrics) AlignTranslation(translated *SyncedLyrics) {
+	if translated == nil || len(translated.Lines) == 0 {
+		return
+	}
+
+	j := 0
+	for i := range sl.Lines {
+		t := sl.Lines[i].Time
+
+		// Both slices are sorted, so advance while the next line is closer
+		for j+1 < len(translated.Lines) && absDuration(translated.Lines[j+1].Time-t) <= absDuration(translated.Lines[j].Time-t) {
+			j++
+		}
+
+		if absDuration(translated.Lines[j].Time-t) <= maxTranslationSkew {
+			sl.Lines[i].Translation = translated.Lines[j].Text
+		}
+	}
+}
+
+// LoadTranslation loads the translated LRC or SRT for a song from dir.
+// Files are named "Artist - Title.lrc" or "Artist - Title.srt", though small
+// differences in case, punctuation and spacing are tolerated, see
+// findLyricsFile.
+func LoadTranslation(dir, artist, title string) (*SyncedLyrics, error) {
+	path, err := findLyricsFile(dir, artist, title)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find translation: %w", err)
+	}
+
+	translation, err := LoadLyricsFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load translation: %w", err)
+	}
+	return translation, nil
+}
+
+// LRCFileName returns the "Artist - Title.lrc" file name used for a song
+func LRCFileName(artist, title string) string {
+	name := fmt.Sprintf("%s - %s.lrc", artist, title)
+	return strings.NewReplacer("/", "_", "\\", "_").Replace(name)
+}
+
+// absDuration returns the absolute value of d
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
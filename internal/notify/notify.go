@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/art"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/debuglog"
+)
+
+// defaultSettleDelay is how long a song must stay current before it is
+// announced, so rapid track skips only produce a single notification
+const defaultSettleDelay = 1500 * time.Millisecond
+
+// Notifier shows desktop notifications for song changes
+type Notifier struct {
+	enabled     bool
+	settleDelay time.Duration
+	art         *art.Cache // Resolves cover art for icons, nil for no icons
+	timer       *time.Timer
+	mu          sync.Mutex
+}
+
+// NewNotifier creates a new notifier. Cover art for icons is loaded
+// through artCache, which may be nil to show no icons.
+func NewNotifier(enabled bool, artCache *art.Cache) *Notifier {
+	return &Notifier{
+		enabled:     enabled,
+		settleDelay: defaultSettleDelay,
+		art:         artCache,
+	}
+}
+
+// SetEnabled enables or disables notifications
+func (n *Notifier) SetEnabled(enabled bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.enabled = enabled
+	if !enabled && n.timer != nil {
+		n.timer.Stop()
+		n.timer = nil
+	}
+}
+
+// Enabled reports whether notifications are enabled
+func (n *Notifier) Enabled() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.enabled
+}
+
+// Notify schedules a notification with the given title and message, and
+// the cover art at artURL as its icon if there is one. A notification that
+// is still pending is replaced by the newer one.
+func (n *Notifier) Notify(title, message, artURL string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.enabled {
+		return
+	}
+
+	if n.timer != nil {
+		n.timer.Stop()
+	}
+	n.timer = time.AfterFunc(n.settleDelay, func() {
+		if err := send(title, message, n.icon(artURL)); err != nil {
+			log.Printf("Failed to show notification: %v", err)
+		}
+	})
+}
+
+// icon returns a local image file for the cover art at artURL, or an empty
+// string if there is none
+func (n *Notifier) icon(artURL string) string {
+	if artURL == "" || n.art == nil {
+		return ""
+	}
+
+	path, err := n.art.File(artURL)
+	if err != nil {
+		debuglog.Printf("no notification icon: %v", err)
+		return ""
+	}
+	return path
+}
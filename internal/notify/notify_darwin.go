@@ -0,0 +1,27 @@
+//go:build darwin
+
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// send shows a notification using AppleScript. AppleScript notifications
+// always carry the app's icon, so icon is ignored.
+func send(title, message, icon string) error {
+	script := fmt.Sprintf("display notification %s with title %s", quote(message), quote(title))
+	cmd := exec.Command("osascript", "-e", script)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("osascript failed: %w", err)
+	}
+	return nil
+}
+
+// quote returns s as an AppleScript string literal
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
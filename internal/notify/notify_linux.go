@@ -0,0 +1,22 @@
+//go:build linux
+
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// send shows a notification using notify-send, with icon as its image if
+// it isn't empty
+func send(title, message, icon string) error {
+	args := []string{"-a", "Lyric Clipboard"}
+	if icon != "" {
+		args = append(args, "-i", icon)
+	}
+	cmd := exec.Command("notify-send", append(args, title, message)...)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("notify-send failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+
+package notify
+
+import "fmt"
+
+// send is not implemented on unsupported platforms
+func send(title, message, icon string) error {
+	return fmt.Errorf("notifications not implemented for this platform")
+}
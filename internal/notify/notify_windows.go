@@ -0,0 +1,42 @@
+//go:build windows
+
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// send shows a balloon notification from a temporary tray icon via PowerShell.
+// The tray icon shows icon, if it isn't empty and can be loaded.
+func send(title, message, icon string) error {
+	script := fmt.Sprintf(`
+Add-Type -AssemblyName System.Windows.Forms
+Add-Type -AssemblyName System.Drawing
+$balloon = New-Object System.Windows.Forms.NotifyIcon
+$balloon.Icon = [System.Drawing.SystemIcons]::Information
+$iconPath = %s
+if ($iconPath -ne '') {
+    try {
+        $bitmap = New-Object System.Drawing.Bitmap $iconPath
+        $balloon.Icon = [System.Drawing.Icon]::FromHandle($bitmap.GetHicon())
+    } catch {}
+}
+$balloon.Visible = $true
+$balloon.ShowBalloonTip(5000, %s, %s, [System.Windows.Forms.ToolTipIcon]::None)
+Start-Sleep -Seconds 6
+$balloon.Dispose()
+`, quote(icon), quote(title), quote(message))
+
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to show balloon notification: %w", err)
+	}
+	return nil
+}
+
+// quote returns s as a single-quoted PowerShell string literal
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,98 @@
+package orchestrator
+
+import (
+	"slices"
+	"time"
+)
+
+// Drift detection limits. Drift is measured from the track start implied
+// by each line crossing (the wall clock minus the reported position), which
+// stays put while the player's position keeps pace with the wall clock.
+const (
+	// driftBaselineLines is how many line crossings at the start of a track
+	// set the baseline. The earliest implied start among them is used, as
+	// it comes from the freshest position report.
+	driftBaselineLines = 4
+	// driftWindow is how many further crossings are needed before drift is
+	// reported, and again after each correction
+	driftWindow = 8
+	// driftThreshold is the smallest drift worth reporting or correcting
+	driftThreshold = 200 * time.Millisecond
+	// maxDriftStep limits each automatic correction, so a burst of stale
+	// readings can't move the lyrics far
+	maxDriftStep = 250 * time.Millisecond
+	// maxDriftCorrection bounds the total automatic correction for a track
+	maxDriftCorrection = 2 * time.Second
+	// maxDriftJump is how far the implied start may move between crossings
+	// before it is treated as a seek or pause rather than drift
+	maxDriftJump = 2 * time.Second
+)
+
+// driftTracker watches whether the player's reported position falls behind
+// or runs ahead of the wall clock over a track. Lag that is constant from
+// the start can't be seen this way; -calibrate measures that.
+type driftTracker struct {
+	baseline  time.Time       // Earliest implied start among the first crossings
+	crossings int             // Crossings seen for the baseline
+	prevStart time.Time       // Implied start at the last crossing
+	samples   []time.Duration // Drift at recent crossings, positive when the position lags
+	lastLine  time.Duration   // Time of the last line crossed
+	hasLine   bool
+}
+
+// reset forgets everything seen, for a new track or after a seek
+func (d *driftTracker) reset() {
+	*d = driftTracker{}
+}
+
+// observe records the line current at the given wall-clock time and
+// reported position. When a new line has been crossed often enough to judge,
+// it returns the median drift over the window and true, and starts a new
+// window. The position is as reported, before any offset.
+func (d *driftTracker) observe(lineTime, position time.Duration, now time.Time) (time.Duration, bool) {
+	if d.hasLine && lineTime == d.lastLine {
+		return 0, false
+	}
+	// Only moving forward one line at a time counts as a crossing
+	forward := !d.hasLine || lineTime > d.lastLine
+	d.lastLine, d.hasLine = lineTime, true
+	if !forward {
+		d.reset()
+		return 0, false
+	}
+
+	start := now.Add(-position)
+	if !d.prevStart.IsZero() {
+		if jump := start.Sub(d.prevStart); jump > maxDriftJump || jump < -maxDriftJump {
+			d.reset()
+			d.lastLine, d.hasLine = lineTime, true
+			d.prevStart = start
+			return 0, false
+		}
+	}
+	d.prevStart = start
+
+	if d.crossings < driftBaselineLines {
+		if d.crossings == 0 || start.Before(d.baseline) {
+			d.baseline = start
+		}
+		d.crossings++
+		return 0, false
+	}
+
+	d.samples = append(d.samples, start.Sub(d.baseline))
+	if len(d.samples) < driftWindow {
+		return 0, false
+	}
+
+	sorted := slices.Clone(d.samples)
+	slices.Sort(sorted)
+	d.samples = d.samples[:0]
+	return sorted[len(sorted)/2], true
+}
+
+// shift moves the baseline after the lyrics were moved by correction, so
+// further drift is measured from the corrected timing
+func (d *driftTracker) shift(correction time.Duration) {
+	d.baseline = d.baseline.Add(correction)
+}
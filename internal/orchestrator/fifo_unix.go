@@ -0,0 +1,127 @@
+//go:build !windows
+
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// fifoRetryInterval is how often the FIFO is opened again while no reader
+// has it open, so a reader that connects gets the current line without
+// waiting for the next one
+const fifoRetryInterval = time.Second
+
+// lineFIFO writes each lyric line, newline-terminated, to a named pipe for
+// status bars such as polybar, waybar or i3blocks to read. Writes never
+// block the loop: without a reader, or with one that isn't keeping up, a
+// line is only kept for the next reader.
+type lineFIFO struct {
+	path string
+	stop chan struct{}
+	once sync.Once
+
+	mu   sync.Mutex
+	fd   int    // Write end of the FIFO, -1 while no reader has it open
+	last string // Latest line, written to readers as they connect
+	err  string // Last error opening the FIFO, so it is logged once
+}
+
+// newLineFIFO creates the FIFO at path unless it already exists
+func newLineFIFO(path string) (*lineFIFO, error) {
+	if err := syscall.Mkfifo(path, 0o600); err != nil && !errors.Is(err, fs.ErrExist) {
+		return nil, fmt.Errorf("failed to create FIFO: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&fs.ModeNamedPipe == 0 {
+		return nil, fmt.Errorf("%s exists and is not a FIFO", path)
+	}
+
+	f := &lineFIFO{path: path, stop: make(chan struct{}), fd: -1}
+	go f.reconnect()
+	return f, nil
+}
+
+// write sends a line to the FIFO's reader, if there is one
+func (f *lineFIFO) write(line string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.last = line
+	f.send()
+}
+
+// send writes the latest line, opening the FIFO first if needed. If the
+// reader went away, the FIFO is opened again once in case it already
+// reconnected. The caller holds f.mu.
+func (f *lineFIFO) send() {
+	for attempt := 0; attempt < 2; attempt++ {
+		if f.fd < 0 {
+			// Opening for writing without blocking fails with ENXIO until
+			// a reader has the FIFO open
+			fd, err := syscall.Open(f.path, syscall.O_WRONLY|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+			if err != nil {
+				if !errors.Is(err, syscall.ENXIO) && err.Error() != f.err {
+					log.Printf("Failed to open FIFO %s: %v", f.path, err)
+					f.err = err.Error()
+				}
+				return
+			}
+			f.fd = fd
+			f.err = ""
+		}
+
+		_, err := syscall.Write(f.fd, []byte(f.last+"\n"))
+		if err == nil || errors.Is(err, syscall.EAGAIN) {
+			// EAGAIN means the pipe is full, so the reader misses a line
+			return
+		}
+
+		// EPIPE: the reader closed its end
+		syscall.Close(f.fd)
+		f.fd = -1
+	}
+}
+
+// reconnect keeps trying to open the FIFO while no reader has it open, and
+// writes the latest line when one connects
+func (f *lineFIFO) reconnect() {
+	ticker := time.NewTicker(fifoRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+			f.mu.Lock()
+			if f.fd < 0 {
+				f.send()
+			}
+			f.mu.Unlock()
+		}
+	}
+}
+
+// Close stops writing to the FIFO. The FIFO itself is left in place, as a
+// reader may still have it open.
+func (f *lineFIFO) Close() error {
+	f.once.Do(func() { close(f.stop) })
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fd >= 0 {
+		syscall.Close(f.fd)
+		f.fd = -1
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package orchestrator
+
+import "fmt"
+
+// lineFIFO is not available on Windows, which has no FIFOs in the file
+// system
+type lineFIFO struct{}
+
+// newLineFIFO reports that FIFOs aren't supported
+func newLineFIFO(path string) (*lineFIFO, error) {
+	return nil, fmt.Errorf("FIFOs are not supported on Windows")
+}
+
+// write does nothing
+func (f *lineFIFO) write(line string) {}
+
+// Close does nothing
+func (f *lineFIFO) Close() error {
+	return nil
+}
@@ -0,0 +1,98 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// lineCommandTimeout is how long a line command may run before it is killed
+const lineCommandTimeout = 10 * time.Second
+
+// lineCommand runs a shell command for every lyric line change
+type lineCommand struct {
+	tmpl *template.Template
+}
+
+// lineCommandData is the data available to the line command template. The
+// values are quoted for the shell, so each expands to a single argument.
+type lineCommandData struct {
+	Line   string
+	Artist string
+	Title  string
+	Album  string
+}
+
+// newLineCommand parses a command template such as
+// `notify-send {{.Artist}} {{.Line}}`
+func newLineCommand(command string) (*lineCommand, error) {
+	tmpl, err := template.New("line_command").Parse(command)
+	if err != nil {
+		return nil, err
+	}
+	return &lineCommand{tmpl: tmpl}, nil
+}
+
+// run executes the command for a line change event and logs failures. The
+// line is empty when the clipboard was cleared, e.g. between songs.
+func (c *lineCommand) run(event Event) {
+	var buf bytes.Buffer
+	err := c.tmpl.Execute(&buf, lineCommandData{
+		Line:   shellQuote(event.Line),
+		Artist: shellQuote(event.Song.Artist),
+		Title:  shellQuote(event.Song.Title),
+		Album:  shellQuote(event.Song.Album),
+	})
+	if err != nil {
+		log.Printf("Failed to render line command: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lineCommandTimeout)
+	defer cancel()
+
+	cmd := shellCommand(ctx, buf.String())
+	// The values are also passed unquoted, for scripts
+	cmd.Env = append(os.Environ(),
+		"LYRIC_LINE="+event.Line,
+		"LYRIC_ARTIST="+event.Song.Artist,
+		"LYRIC_TITLE="+event.Song.Title,
+		"LYRIC_ALBUM="+event.Song.Album,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		log.Printf("Line command timed out after %v", lineCommandTimeout)
+		return
+	}
+	if err == nil {
+		return
+	}
+	if output := strings.TrimSpace(string(output)); output != "" {
+		log.Printf("Line command failed: %v: %s", err, output)
+	} else {
+		log.Printf("Line command failed: %v", err)
+	}
+}
+
+// shellCommand runs command through the platform's shell
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// shellQuote quotes s as a single argument for the platform's shell
+func shellQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
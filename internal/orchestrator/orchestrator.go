@@ -1,75 +1,634 @@
 package orchestrator
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/art"
 	"github.com/arnavpraneet/lyric-clipboard-app/internal/clipboard"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/clock"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/debuglog"
 	"github.com/arnavpraneet/lyric-clipboard-app/internal/detector"
 	"github.com/arnavpraneet/lyric-clipboard-app/internal/lyrics"
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/notify"
 )
 
 // Orchestrator is the core component that coordinates all modules
 type Orchestrator struct {
-	detector          detector.Detector
-	lyricsFetcher     *lyrics.Fetcher
-	clipboardMgr      *clipboard.Manager
-	pollInterval      time.Duration
-	lyricOffset       time.Duration
-	updateClipboard   bool
-	currentSongKey    string
-	currentLyrics     *lyrics.SyncedLyrics
-	lastLyricText     string
-	stopChan          chan struct{}
-	statusCallback    func(status string)
+	detector         detector.Detector
+	clock            clock.Clock
+	lyricsFetcher    LyricsProvider
+	clipboardMgr     ClipboardWriter
+	notifier         *notify.Notifier
+	fifo             *lineFIFO  // Nil unless FIFOPath is set
+	art              *art.Cache // Cover art of recent songs, shared with the notifier
+	pollInterval     time.Duration
+	adaptivePolling  bool
+	romanize         bool
+	transliterator   lyrics.Transliterator
+	annotations      []*regexp.Regexp // Patterns stripped from lyric lines, nil if disabled
+	showTranslation  bool
+	translationDir   string
+	clipboardTmpl    *template.Template
+	includeTimestamp bool
+	instrumentalText string
+	radioMode        bool
+	longTrack        time.Duration // Tracks at least this long are taken for spoken word, 0 to disable
+	clipboardMode    string
+	clipboardMaxLen  int
+	maxLineLength    int
+	historyLines     int
+	forceRewrite     bool     // Write repeated lines again at each new timestamp
+	copyOnDemand     bool     // Only write lines when CopyCurrentLine is called
+	history          []string // Latest clipboard lines for the current song, see historyText
+	clearOnTrackEnd  bool
+	clearOnNoSong    bool
+	noSongText       string
+	trackEndWindow   time.Duration
+	trackEnded       bool
+	minLineDisplay   time.Duration
+	detectDrift      bool
+	autoOffset       bool
+	drift            driftTracker
+	driftCorrection  time.Duration // Automatic correction for the current track, see checkDrift
+	driftReported    bool          // Drift was logged for the current track
+	lineShownTime    time.Duration // Timestamp of the lyric line last shown
+	lineShownAt      time.Time
+	quietHours       *quietHours // Nil if there are none
+	wasQuiet         bool
+	hideLineLog      bool
+
+	// Clipboard write coalescing, see writeClipboard
+	clipboardDebounce    time.Duration
+	clipboardMinInterval time.Duration
+	lastClipboardWrite   time.Time
+	lastClipboardText    string // What was last written, see clearNoSong
+	clipboardMu          sync.Mutex
+	clipboardTimer       clock.Timer
+	pendingClipboard     string
+	hasPending           bool
+
+	// Song state and settings are shared between the loop and other
+	// goroutines, e.g. the tray. The loop reads its own state without mu but
+	// takes it to write; everything else takes mu.
+	mu              sync.RWMutex
+	lyricOffset     time.Duration
+	leadTime        time.Duration
+	updateClipboard bool
+	paused          bool
+	reloadRequested bool
+	state           State
+	currentSong     *detector.SongInfo
+	currentSongKey  string
+	currentLyrics   *lyrics.SyncedLyrics
+	spokenWord      bool // Current track is a podcast or audiobook, so no lyrics were looked up
+	lastLyricText   string
+	lineClipboard   string // Clipboard text for the current line, for CopyCurrentLine
+	lastPosition    time.Duration
+
+	// Lyrics versions found for the current track and one the user picked,
+	// which the loop switches to on its next tick
+	candidates      []lyrics.Candidate
+	candidatesTrack string
+	chosenLyrics    *lyrics.SyncedLyrics
+	chosenTrack     string
+
+	// Line picked with NextLine or PrevLine, shown instead of the timed line
+	// until manualUntil
+	manualIndex int
+	manualTrack string
+	manualUntil time.Time
+
+	currentTrack        string    // Identity of the current track, see trackKey
+	lastFetch           time.Time // When lyrics were last loaded successfully
+	lastDetectorErr     string
+	detectorFailures    int
+	maxDetectorFailures int
+	implausibleReadings int // Consecutive readings with an implausible position
+	startupDelay        time.Duration
+	warmupUntil         time.Time // Detection errors are quiet until then, see warmupPeriod
+	stopChan            chan struct{}
+	statusCallback      func(status string)
+	eventHandlers       []func(Event)
+}
+
+// EventType identifies the kind of change an Event describes
+type EventType int
+
+const (
+	// EventSongChange is emitted when a new song is detected
+	EventSongChange EventType = iota
+	// EventLineChange is emitted when the current lyric line changes
+	EventLineChange
+	// EventStateChange is emitted when the orchestrator's State changes
+	EventStateChange
+)
+
+// String returns the event type's name as used in external APIs
+func (t EventType) String() string {
+	switch t {
+	case EventSongChange:
+		return "song_change"
+	case EventLineChange:
+		return "line_change"
+	case EventStateChange:
+		return "state_change"
+	default:
+		return "unknown"
+	}
+}
+
+// State summarizes what the orchestrator is doing, for status displays
+type State int
+
+const (
+	// StateIdle means no song is playing
+	StateIdle State = iota
+	// StateFetching means a song was detected and its lyrics are being fetched
+	StateFetching
+	// StateActive means lyrics are loaded and being synced
+	StateActive
+	// StateNoLyrics means no lyrics were found for the current song
+	StateNoLyrics
+	// StateError means song detection or the lyrics fetch failed
+	StateError
+)
+
+// String returns the state's name as used in external APIs
+func (s State) String() string {
+	switch s {
+	case StateIdle:
+		return "idle"
+	case StateFetching:
+		return "fetching"
+	case StateActive:
+		return "active"
+	case StateNoLyrics:
+		return "no_lyrics"
+	case StateError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes a playback or lyric change
+type Event struct {
+	Type  EventType
+	Song  detector.SongInfo
+	Line  string // Current lyric line, set for EventLineChange
+	State State  // New state, set for EventStateChange
+}
+
+// LyricsProvider looks up the lyrics for a song, such as *lyrics.Fetcher
+type LyricsProvider interface {
+	FetchLyrics(artist, title, album string, duration time.Duration) (*lyrics.SyncedLyrics, error)
+}
+
+// LyricsInvalidator is implemented by lyrics providers that cache results,
+// allowing a song's lyrics to be fetched again
+type LyricsInvalidator interface {
+	Invalidate(artist, title, album string, duration time.Duration)
+}
+
+// LyricsSearcher is implemented by lyrics providers that can list every
+// version of a song's lyrics and switch to one of them
+type LyricsSearcher interface {
+	Search(artist, title string, duration time.Duration, limit int) ([]lyrics.Candidate, error)
+	UseCandidate(artist, title, album string, duration time.Duration, candidate lyrics.Candidate) (*lyrics.SyncedLyrics, error)
+}
+
+// MaxLyricsCandidates is how many lyrics versions are offered for a song
+const MaxLyricsCandidates = 5
+
+// ClipboardWriter writes text to a clipboard, such as *clipboard.Manager
+type ClipboardWriter interface {
+	Write(text string) error
+}
+
+// CacheStatsReporter is implemented by lyrics providers that cache results
+// and keep statistics about it
+type CacheStatsReporter interface {
+	Stats() lyrics.CacheStats
+}
+
+// ClockedDetector is implemented by simulated detectors, such as the demo
+// and scripted detectors, that can follow the orchestrator's clock
+type ClockedDetector interface {
+	SetClock(c clock.Clock)
+}
+
+// ClipboardReader is implemented by clipboards that can be read back, which
+// append mode requires
+type ClipboardReader interface {
+	Read() (string, error)
 }
 
+// Clipboard modes
+const (
+	// ClipboardModeReplace overwrites the clipboard with each new line
+	ClipboardModeReplace = "replace"
+	// ClipboardModeAppend adds each new line to the end of the clipboard
+	ClipboardModeAppend = "append"
+)
+
 // Config holds configuration for the orchestrator
 type Config struct {
-	PollInterval    time.Duration // How often to check for song updates
-	LyricOffset     time.Duration // Time offset to apply to lyrics
-	UpdateClipboard bool          // Enable clipboard updates
-	DemoMode        bool          // Run in demo mode
-	DemoArtist      string        // Artist for demo mode
-	DemoTitle       string        // Title for demo mode
+	PollInterval         time.Duration // How often to check for song updates
+	LyricOffset          time.Duration // Time offset to apply to lyrics
+	UpdateClipboard      bool          // Enable clipboard updates
+	EnableCache          bool          // Cache fetched lyrics
+	DemoMode             bool          // Run in demo mode
+	DemoArtist           string        // Artist for demo mode
+	DemoTitle            string        // Title for demo mode
+	ShowNotifications    bool          // Show notifications for song changes
+	AdaptivePolling      bool          // Schedule polls around lyric line boundaries
+	Romanize             bool          // Prefer romanized lyric text
+	ShowTranslation      bool          // Include translations in clipboard text
+	TranslationDir       string        // Directory of translated LRC files
+	ClipboardTemplate    string        // Go template for clipboard text
+	PreferredPlayers     []string      // Players to check first
+	InstrumentalText     string        // Clipboard text for instrumental tracks
+	ClipboardDebounce    time.Duration // Window for coalescing clipboard writes
+	UserAgent            string        // User-Agent for lyrics requests, empty for the default
+	CacheMaxEntries      int           // Maximum number of songs in the lyrics cache
+	ClipboardMode        string        // ClipboardModeReplace (default) or ClipboardModeAppend
+	ClipboardMaxLength   int           // Maximum clipboard length in append mode
+	ClearOnTrackEnd      bool          // Clear the clipboard near the end of a track
+	TrackEndWindow       time.Duration // Time before the end at which a track counts as finished
+	ClipboardBackend     string        // Clipboard backend name, empty to detect
+	ClipboardTimeout     time.Duration // Timeout for clipboard commands
+	IncludeTimestamp     bool          // Prefix clipboard text with the line timestamp
+	MaxDetectorFailures  int           // Consecutive detection failures tolerated before clearing state
+	LeadTime             time.Duration // How early the next line may be shown
+	MinLineDisplay       time.Duration // Minimum time each line stays current
+	NegativeCacheTTL     time.Duration // How long songs without lyrics are remembered
+	FetchTimeout         time.Duration // Overall deadline for a lyrics lookup
+	DemoLRC              string        // LRC or SRT file played offline in demo mode
+	RadioMode            bool          // Split stream titles and skip line timing for streams
+	SpotifyToken         string        // Spotify Web API access token
+	SpotifyClientID      string        // Spotify app client ID
+	SpotifyClientSecret  string        // Spotify app client secret
+	SpotifyRefreshToken  string        // Spotify refresh token
+	OnLineCommand        string        // Shell command template run on every line change
+	PlayerSelection      string        // Policy for choosing among playing players
+	QuietHoursStart      string        // Start of the daily window without clipboard updates, "HH:MM"
+	QuietHoursEnd        string        // End of the quiet hours, "HH:MM"
+	StripAnnotations     bool          // Remove annotations from lyric lines
+	AnnotationPatterns   []string      // Annotation regular expressions, nil for the defaults
+	LyricsRateLimit      float64       // Lyrics API requests allowed per second
+	HideLineLog          bool          // Don't log each lyric line, e.g. when a TUI shows it
+	IgnoredPlayers       []string      // Players never followed
+	ClipboardMinInterval time.Duration // Least time between clipboard writes
+	StartupDelay         time.Duration // Wait before the first tick
+	MaxLineLength        int           // Maximum clipboard text length in characters, 0 for no limit
+
+	ClearClipboardOnNoSong bool   // Replace the last lyric with NoSongText when no song is detected
+	NoSongText             string // Clipboard text for the no-song state
+	DetectDrift            bool   // Log when lyrics drift out of time over a track
+	AutoOffset             bool   // Correct drift automatically for the rest of the track
+	ClipboardHistoryLines  int    // Number of latest lines kept on the clipboard
+	PlayerctlFallback      bool   // Ask playerctl when D-Bus finds no song (Linux)
+	SkipLongTracksMinutes  int    // Tracks at least this long are treated as spoken word, 0 to disable
+	FIFOPath               string // Named pipe to write each line to, empty to disable
+	ForceRewriteDuplicates bool   // Write a repeated line again at each new timestamp
+	LocalDBPath            string // lrclib database dump searched before lrclib.net, empty to disable
+	CopyOnDemand           bool   // Track lines without writing them; CopyCurrentLine copies the current one
+
+	// Clock tells the time and schedules ticks. Nil uses the system clock;
+	// tests may pass a clock.Fake to step through lines without waiting.
+	Clock clock.Clock
+
+	// Transliterator romanizes lyrics when Romanize is set.
+	// Defaults to lyrics.DefaultTransliterator.
+	Transliterator lyrics.Transliterator
 }
 
 // NewOrchestrator creates a new orchestrator with the given configuration
 func NewOrchestrator(config Config) (*Orchestrator, error) {
+	clip, err := clipboard.NewManager(clipboard.Options{
+		Backend:        config.ClipboardBackend,
+		CommandTimeout: config.ClipboardTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Play a local LRC file without touching the network
+	if config.DemoMode && config.DemoLRC != "" {
+		return newLRCDemo(clip, config)
+	}
+
 	var det detector.Detector
-	var err error
 
 	if config.DemoMode {
 		det = detector.NewDemoDetector(config.DemoArtist, config.DemoTitle)
 	} else {
-		det, err = detector.NewDetector()
+		det, err = detector.NewDetector(detector.Options{
+			PreferredPlayers:  config.PreferredPlayers,
+			PlayerSelection:   config.PlayerSelection,
+			SplitStreamTitles: config.RadioMode,
+			IgnoredPlayers:    config.IgnoredPlayers,
+			PlayerctlFallback: config.PlayerctlFallback,
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to create detector: %w", err)
 		}
+
+		// Fall back to Spotify's Web API when no local player is playing
+		if config.SpotifyToken != "" || config.SpotifyRefreshToken != "" {
+			spotify, err := detector.NewSpotifyDetector(detector.SpotifyOptions{
+				AccessToken:  config.SpotifyToken,
+				ClientID:     config.SpotifyClientID,
+				ClientSecret: config.SpotifyClientSecret,
+				RefreshToken: config.SpotifyRefreshToken,
+			})
+			if err != nil {
+				return nil, err
+			}
+			det = detector.NewFallbackDetector(det, spotify)
+			log.Println("Using Spotify Web API when no local player is playing")
+		}
+	}
+
+	fetcher := lyrics.NewFetcher()
+	fetcher.SetCacheEnabled(config.EnableCache)
+	if config.CacheMaxEntries != 0 {
+		fetcher.SetCacheMaxEntries(config.CacheMaxEntries)
+	}
+	if config.FetchTimeout != 0 {
+		fetcher.SetFetchTimeout(config.FetchTimeout)
+	}
+	if config.LyricsRateLimit != 0 {
+		fetcher.SetRateLimit(config.LyricsRateLimit)
+	}
+	if config.NegativeCacheTTL != 0 {
+		fetcher.SetNegativeCacheTTL(config.NegativeCacheTTL)
+	}
+	if config.UserAgent != "" {
+		fetcher.SetUserAgent(config.UserAgent)
+	}
+	if config.LocalDBPath != "" {
+		// Without the database, lyrics still come from lrclib.net
+		if db, err := lyrics.OpenLocalDB(config.LocalDBPath); err != nil {
+			log.Printf("Not using the local lyrics database: %v", err)
+		} else {
+			fetcher.SetLocalDB(db)
+			log.Printf("Looking up lyrics in %s before lrclib.net", config.LocalDBPath)
+		}
+	}
+
+	return NewOrchestratorWith(det, fetcher, clip, config)
+}
+
+// demoOutro is how long an LRC demo keeps playing after its last line
+// when the file has no [length:] tag
+const demoOutro = 5 * time.Second
+
+// newLRCDemo creates an orchestrator that plays the lyrics in config.DemoLRC
+// on repeat. The song is named by the LRC file's [ar:] and [ti:] tags,
+// falling back to DemoArtist and DemoTitle, which SRT files always use.
+func newLRCDemo(clip ClipboardWriter, config Config) (*Orchestrator, error) {
+	demoLyrics, err := lyrics.LoadLyricsFile(config.DemoLRC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load demo lyrics: %w", err)
+	}
+
+	artist, title := demoLyrics.Artist, demoLyrics.Title
+	if artist == "" {
+		artist = config.DemoArtist
+	}
+	if title == "" {
+		title = config.DemoTitle
+	}
+
+	length := demoLyrics.Length
+	if length == 0 {
+		length = demoLyrics.Lines[len(demoLyrics.Lines)-1].Time + demoOutro
+	}
+
+	det := detector.NewLoopingDemoDetector(artist, title, length)
+	return NewOrchestratorWith(det, staticLyrics{demoLyrics}, clip, config)
+}
+
+// staticLyrics is a LyricsProvider that returns the same lyrics for any song
+type staticLyrics struct {
+	lyrics *lyrics.SyncedLyrics
+}
+
+// FetchLyrics returns the provider's lyrics
+func (s staticLyrics) FetchLyrics(artist, title, album string, duration time.Duration) (*lyrics.SyncedLyrics, error) {
+	return s.lyrics, nil
+}
+
+// NewOrchestratorWith creates an orchestrator that uses the given detector,
+// lyrics provider and clipboard instead of the platform defaults. The
+// DemoMode and PreferredPlayers settings are ignored.
+func NewOrchestratorWith(det detector.Detector, fetcher LyricsProvider, clip ClipboardWriter, config Config) (*Orchestrator, error) {
+	transliterator := config.Transliterator
+	if transliterator == nil {
+		transliterator = lyrics.DefaultTransliterator
+	}
+
+	var clipboardTmpl *template.Template
+	if config.ClipboardTemplate != "" {
+		var err error
+		clipboardTmpl, err = template.New("clipboard").Parse(config.ClipboardTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid clipboard template: %w", err)
+		}
+	}
+
+	var onLine *lineCommand
+	if config.OnLineCommand != "" {
+		var err error
+		onLine, err = newLineCommand(config.OnLineCommand)
+		if err != nil {
+			return nil, fmt.Errorf("invalid line command: %w", err)
+		}
+	}
+
+	var annotations []*regexp.Regexp
+	if config.StripAnnotations {
+		var err error
+		annotations, err = lyrics.CompileAnnotationPatterns(config.AnnotationPatterns)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	quiet, err := parseQuietHours(config.QuietHoursStart, config.QuietHoursEnd)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours: %w", err)
+	}
+
+	clipboardMode := config.ClipboardMode
+	switch clipboardMode {
+	case "":
+		clipboardMode = ClipboardModeReplace
+	case ClipboardModeReplace, ClipboardModeAppend:
+	default:
+		return nil, fmt.Errorf("invalid clipboard mode %q", config.ClipboardMode)
+	}
+
+	maxDetectorFailures := config.MaxDetectorFailures
+	if maxDetectorFailures < 1 {
+		maxDetectorFailures = 1
+	}
+
+	// Created last, as it starts a goroutine
+	var fifo *lineFIFO
+	if config.FIFOPath != "" {
+		fifo, err = newLineFIFO(config.FIFOPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	clk := config.Clock
+	if clk == nil {
+		clk = clock.Real
+	} else if clocked, ok := det.(ClockedDetector); ok {
+		// Keep the simulated playback in step with the loop
+		clocked.SetClock(clk)
+	}
+
+	artCache := art.NewCache()
+
+	o := &Orchestrator{
+		detector:             det,
+		clock:                clk,
+		art:                  artCache,
+		lyricsFetcher:        fetcher,
+		clipboardMgr:         clip,
+		notifier:             notify.NewNotifier(config.ShowNotifications, artCache),
+		pollInterval:         config.PollInterval,
+		lyricOffset:          config.LyricOffset,
+		leadTime:             config.LeadTime,
+		updateClipboard:      config.UpdateClipboard,
+		adaptivePolling:      config.AdaptivePolling,
+		romanize:             config.Romanize,
+		transliterator:       transliterator,
+		annotations:          annotations,
+		showTranslation:      config.ShowTranslation,
+		translationDir:       config.TranslationDir,
+		clipboardTmpl:        clipboardTmpl,
+		includeTimestamp:     config.IncludeTimestamp,
+		maxDetectorFailures:  maxDetectorFailures,
+		startupDelay:         config.StartupDelay,
+		instrumentalText:     config.InstrumentalText,
+		radioMode:            config.RadioMode,
+		longTrack:            time.Duration(config.SkipLongTracksMinutes) * time.Minute,
+		clipboardMode:        clipboardMode,
+		clipboardMaxLen:      config.ClipboardMaxLength,
+		maxLineLength:        config.MaxLineLength,
+		historyLines:         config.ClipboardHistoryLines,
+		forceRewrite:         config.ForceRewriteDuplicates,
+		copyOnDemand:         config.CopyOnDemand,
+		clearOnTrackEnd:      config.ClearOnTrackEnd,
+		clearOnNoSong:        config.ClearClipboardOnNoSong,
+		noSongText:           config.NoSongText,
+		hideLineLog:          config.HideLineLog,
+		trackEndWindow:       config.TrackEndWindow,
+		minLineDisplay:       config.MinLineDisplay,
+		detectDrift:          config.DetectDrift || config.AutoOffset,
+		autoOffset:           config.AutoOffset,
+		quietHours:           quiet,
+		clipboardDebounce:    config.ClipboardDebounce,
+		clipboardMinInterval: config.ClipboardMinInterval,
+		stopChan:             make(chan struct{}),
+	}
+
+	// Announce new songs with a desktop notification
+	o.AddEventHandler(func(event Event) {
+		if event.Type == EventSongChange {
+			o.notifier.Notify(event.Song.Title, event.Song.Artist, event.Song.ArtURL)
+		}
+	})
+
+	// Run the user's command on every line without holding up the loop
+	if onLine != nil {
+		o.AddEventHandler(func(event Event) {
+			if event.Type == EventLineChange {
+				go onLine.run(event)
+			}
+		})
 	}
 
-	return &Orchestrator{
-		detector:        det,
-		lyricsFetcher:   lyrics.NewFetcher(),
-		clipboardMgr:    clipboard.NewManager(),
-		pollInterval:    config.PollInterval,
-		lyricOffset:     config.LyricOffset,
-		updateClipboard: config.UpdateClipboard,
-		stopChan:        make(chan struct{}),
-	}, nil
+	// Feed status bars through the FIFO, which never blocks
+	if fifo != nil {
+		o.fifo = fifo
+		o.AddEventHandler(func(event Event) {
+			if event.Type == EventLineChange {
+				fifo.write(event.Line)
+			}
+		})
+	}
+
+	return o, nil
 }
 
-// Start begins the orchestrator's main loop
+// positionSlack is how far past the track's end a reported position may be
+// before it is considered implausible
+const positionSlack = 5 * time.Second
+
+// maxImplausibleReadings is how many consecutive implausible positions are
+// skipped before they are accepted, in case the player always reports them
+const maxImplausibleReadings = 5
+
+// manualLineHold is how long a line picked with NextLine or PrevLine stays
+// before the lyrics follow the playback position again
+const manualLineHold = 5 * time.Second
+
+// Limits for the delay between polls in adaptive polling mode
+const (
+	// minAdaptiveInterval keeps rapid lines from turning into a busy loop
+	minAdaptiveInterval = 20 * time.Millisecond
+	// maxAdaptiveInterval ensures song changes and seeks are noticed promptly
+	maxAdaptiveInterval = 1 * time.Second
+	// adaptiveLead is how long before a line boundary to wake up
+	adaptiveLead = 30 * time.Millisecond
+)
+
+// warmupPeriod is how long after the first tick detection errors are only
+// logged at debug level, while the session bus and players start up. It
+// ends early once a song is detected.
+const warmupPeriod = 30 * time.Second
+
+// Start begins the orchestrator's main loop. The first tick waits for the
+// startup delay, if one is set.
 func (o *Orchestrator) Start() {
 	log.Println("Starting Lyric Clipboard App...")
-	ticker := time.NewTicker(o.pollInterval)
-	defer ticker.Stop()
+	first := o.pollInterval
+	if o.startupDelay > 0 {
+		log.Printf("Waiting %v before detecting songs", o.startupDelay)
+		first = o.startupDelay
+	}
+	timer := o.clock.NewTimer(first)
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
-			o.tick()
+		case <-timer.C():
+			if o.warmupUntil.IsZero() {
+				o.warmupUntil = o.clock.Now().Add(warmupPeriod)
+			}
+			if !o.IsPaused() {
+				o.tick()
+			}
+			timer.Reset(o.nextPollDelay())
 		case <-o.stopChan:
 			log.Println("Stopping orchestrator...")
 			return
@@ -77,40 +636,219 @@ func (o *Orchestrator) Start() {
 	}
 }
 
+// nextPollDelay returns how long to wait before the next tick.
+// In adaptive mode this is just before the next lyric line is due.
+func (o *Orchestrator) nextPollDelay() time.Duration {
+	if !o.adaptivePolling || o.currentLyrics == nil {
+		return o.pollInterval
+	}
+
+	// Lines shown early by the lead time are already on the clipboard
+	leadTime := o.GetLeadTime()
+	next, ok := o.currentLyrics.NextLineTime(o.lastPosition + leadTime)
+	if !ok {
+		return o.pollInterval
+	}
+
+	delay := next - leadTime - o.lastPosition - adaptiveLead
+
+	// The next line can't replace the current one until it has been shown
+	// for the minimum time
+	if hold := o.minLineDisplay - o.clock.Now().Sub(o.lineShownAt); hold > delay {
+		delay = hold
+	}
+
+	if delay < minAdaptiveInterval {
+		delay = minAdaptiveInterval
+	}
+	if delay > maxAdaptiveInterval {
+		delay = maxAdaptiveInterval
+	}
+	return delay
+}
+
 // tick performs one iteration of the main loop
 func (o *Orchestrator) tick() {
+	if quiet := o.InQuietHours(); quiet != o.wasQuiet {
+		o.wasQuiet = quiet
+		if quiet {
+			log.Println("Quiet hours started, leaving the clipboard alone")
+		} else {
+			log.Println("Quiet hours ended, resuming clipboard updates")
+		}
+	}
+
 	// Get current song
 	songInfo, err := o.detector.GetCurrentSong()
 	if err != nil {
+		// Players and the session bus may not be up yet right after login
+		warmingUp := o.clock.Now().Before(o.warmupUntil)
+		if warmingUp && !errors.Is(err, detector.ErrNoSong) {
+			if o.lastDetectorErr == "" {
+				debuglog.Printf("song detection not ready yet: %v", err)
+				o.lastDetectorErr = err.Error()
+			}
+			return
+		}
+
+		// Report real detection failures, but only once until they change
+		if !errors.Is(err, detector.ErrNoSong) && err.Error() != o.lastDetectorErr {
+			log.Printf("ERROR: song detection failed: %v", err)
+			o.lastDetectorErr = err.Error()
+		}
+
+		// Keep the current song through brief failures, e.g. a PowerShell
+		// call failing while the screen is locked. Nothing playing isn't a
+		// failure and clears at once.
+		if errors.Is(err, detector.ErrNoSong) {
+			o.detectorFailures = 0
+		} else {
+			o.detectorFailures++
+			if o.detectorFailures < o.maxDetectorFailures {
+				return
+			}
+		}
+
+		if errors.Is(err, detector.ErrNoSong) {
+			o.setState(StateIdle, detector.SongInfo{})
+		} else {
+			o.setState(StateError, detector.SongInfo{})
+		}
+
 		// No song playing or detection failed - clear state
 		if o.currentSongKey != "" {
 			log.Println("No song detected, clearing state")
+			o.mu.Lock()
+			o.currentSong = nil
 			o.currentSongKey = ""
 			o.currentLyrics = nil
+			o.spokenWord = false
 			o.lastLyricText = ""
+			o.lineClipboard = ""
+			o.mu.Unlock()
+			o.currentTrack = ""
+			o.clearNoSong()
 		}
 		return
 	}
+	o.lastDetectorErr = ""
+	o.detectorFailures = 0
+	o.warmupUntil = o.clock.Now() // A song was found, so warmup is over
+
+	// Players can report a garbage position around track changes. Wait for a
+	// plausible one rather than show the wrong line, unless it persists.
+	if problem := implausiblePosition(songInfo); problem != "" && o.implausibleReadings < maxImplausibleReadings {
+		o.implausibleReadings++
+		debuglog.Printf("ignoring reading for %s - %s: %s", songInfo.Artist, songInfo.Title, problem)
+		return
+	}
+	o.implausibleReadings = 0
 
 	// Create a unique key for this song
 	songKey := fmt.Sprintf("%s - %s", songInfo.Artist, songInfo.Title)
 
+	o.mu.Lock()
+	o.currentSong = songInfo
+	o.mu.Unlock()
+
 	// Check if this is a new song
-	if songKey != o.currentSongKey {
+	if track := trackKey(songInfo); track != o.currentTrack {
+		o.currentTrack = track
 		log.Printf("New song detected: %s", songKey)
+		if songInfo.PositionEstimated {
+			log.Println("Player does not report a position, estimating from elapsed time")
+		}
+		o.mu.Lock()
 		o.currentSongKey = songKey
+		o.currentLyrics = nil
+		o.spokenWord = false
 		o.lastLyricText = ""
+		o.lineClipboard = ""
+		o.candidates = nil
+		o.candidatesTrack = ""
+		o.mu.Unlock()
+		o.trackEnded = false
+		o.history = nil
+		o.drift.reset()
+		o.driftCorrection = 0
+		o.driftReported = false
+		o.emit(Event{Type: EventSongChange, Song: *songInfo})
+
+		// Podcasts and audiobooks have no lyrics, so don't look them up
+		if spoken, reason := isSpokenWord(songInfo, o.longTrack); spoken {
+			log.Printf("Skipping lyrics for %s: looks like a podcast or audiobook (%s)", songKey, reason)
+			o.mu.Lock()
+			o.spokenWord = true
+			o.mu.Unlock()
+			o.setState(StateNoLyrics, *songInfo)
+			return
+		}
+
+		o.setState(StateFetching, *songInfo)
 
 		// Fetch lyrics for the new song
-		lyrics, err := o.lyricsFetcher.FetchLyrics(songInfo.Artist, songInfo.Title)
+		songLyrics, err := o.loadLyrics(songInfo)
+		if errors.Is(err, lyrics.ErrLyricsNotFound) || errors.Is(err, lyrics.ErrNoSyncedLyrics) {
+			log.Printf("No lyrics found for %s", songKey)
+			o.setState(StateNoLyrics, *songInfo)
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Printf("Timed out fetching lyrics for %s", songKey)
+			o.setState(StateError, *songInfo)
+			return
+		}
 		if err != nil {
-			log.Printf("Failed to fetch lyrics for %s: %v", songKey, err)
-			o.currentLyrics = nil
+			log.Printf("ERROR: failed to fetch lyrics for %s: %v", songKey, err)
+			o.setState(StateError, *songInfo)
 			return
 		}
 
-		o.currentLyrics = lyrics
-		log.Printf("Lyrics fetched successfully (%d lines)", len(lyrics.Lines))
+		o.mu.Lock()
+		o.currentLyrics = songLyrics
+		o.mu.Unlock()
+		o.setState(StateActive, *songInfo)
+		if songLyrics.Instrumental {
+			log.Printf("%s is instrumental", songKey)
+		} else if len(songLyrics.Lines) == 0 {
+			log.Printf("Only plain lyrics available for %s", songKey)
+		} else {
+			log.Printf("Lyrics fetched successfully (%d lines)", len(songLyrics.Lines))
+		}
+	}
+
+	// Fetch the lyrics again if asked to, e.g. because they didn't match
+	o.mu.Lock()
+	reload := o.reloadRequested
+	o.reloadRequested = false
+	o.mu.Unlock()
+	if reload {
+		o.reloadLyrics(songInfo)
+	}
+
+	// Switch to the lyrics version the user picked
+	o.mu.Lock()
+	chosen, chosenTrack := o.chosenLyrics, o.chosenTrack
+	o.chosenLyrics = nil
+	if chosen != nil && chosenTrack == o.currentTrack {
+		o.currentLyrics = chosen
+		o.lastLyricText = ""
+	}
+	o.mu.Unlock()
+	if chosen != nil && chosenTrack == o.currentTrack {
+		o.trackEnded = false
+		o.setState(StateActive, *songInfo)
+		log.Printf("Switched lyrics version for %s", songKey)
+		o.setStatus("Lyrics version changed")
+	}
+
+	// Radio streams have no position to time lines against, so show the song
+	// itself, once per track
+	if o.radioMode && isStream(songInfo) {
+		if o.lastLyricText == "" {
+			o.showLine(songKey, songKey, songInfo)
+		}
+		return
 	}
 
 	// If we don't have lyrics, nothing to do
@@ -118,38 +856,563 @@ func (o *Orchestrator) tick() {
 		return
 	}
 
-	// Apply lyric offset to playback position
-	adjustedPosition := songInfo.Position + o.lyricOffset
+	// Don't leave the final line on the clipboard between tracks
+	if o.clearOnTrackEnd && o.atTrackEnd(songInfo) {
+		if !o.trackEnded {
+			log.Println("Track ended, clearing clipboard")
+			o.showLine("", "", songInfo)
+			o.trackEnded = true
+		}
+		return
+	}
+	o.trackEnded = false
+
+	// Instrumental tracks get a single placeholder instead of lyric lines
+	if o.currentLyrics.Instrumental {
+		if o.lastLyricText == "" {
+			o.showLine(o.instrumentalText, o.instrumentalText, songInfo)
+		}
+		return
+	}
+
+	// Apply lyric offset to playback position. A negative offset can put the
+	// start of the song before zero.
+	adjustedPosition := songInfo.Position + o.GetLyricOffset() + o.driftCorrection
+	if adjustedPosition < 0 {
+		adjustedPosition = 0
+	}
+	o.mu.Lock()
+	o.lastPosition = adjustedPosition
+	o.mu.Unlock()
 
 	// Get the current lyric line based on adjusted playback position
 	currentLine := o.currentLyrics.GetLineAtTime(adjustedPosition)
+	if o.detectDrift && currentLine != nil && songInfo.IsPlaying && !songInfo.PositionEstimated {
+		o.checkDrift(currentLine.Time, songInfo.Position)
+	}
+
+	// Show the next line early if it's within the lead time. Looking up the
+	// next line's own time means we never get more than one line ahead.
+	if leadTime := o.GetLeadTime(); leadTime > 0 {
+		if next, ok := o.currentLyrics.NextLineTime(adjustedPosition); ok && next-adjustedPosition <= leadTime {
+			currentLine = o.currentLyrics.GetLineAtTime(next)
+		}
+	}
+
+	// A line picked by hand overrides the timing for a while
+	manual := o.manualLine(songInfo)
+	if manual != nil {
+		currentLine = manual
+	}
+
 	if currentLine == nil {
+		// Before the first line, e.g. after seeking back into the intro,
+		// don't leave a later line on the clipboard
+		if o.lastLyricText != "" {
+			o.showLine("", "", songInfo)
+		}
 		return
 	}
 
-	// Update clipboard if the lyric has changed
-	if currentLine.Text != o.lastLyricText {
-		log.Printf("[%s] %s", formatDuration(songInfo.Position), currentLine.Text)
+	// Update clipboard if the lyric has changed. A line repeated at a new
+	// timestamp, e.g. in a chorus, is written again only if asked to.
+	changed := currentLine.Text != o.lastLyricText
+	if !changed && o.forceRewrite && currentLine.Time != o.lineShownTime {
+		changed = true
+	}
+	if changed {
+		// Give the user time to paste the current line. Once it has been shown
+		// long enough, the newest line replaces it, skipping any in between.
+		if o.lastLyricText != "" && manual == nil && o.clock.Now().Sub(o.lineShownAt) < o.minLineDisplay {
+			return
+		}
 
-		if o.updateClipboard {
-			if err := o.clipboardMgr.Write(currentLine.Text); err != nil {
-				log.Printf("Failed to update clipboard: %v", err)
-				return
-			}
+		if !o.hideLineLog {
+			log.Printf("[%s] %s", formatDuration(songInfo.Position), currentLine.Text)
 		}
+		o.showLine(currentLine.Text, o.clipboardText(currentLine, songInfo), songInfo)
+		o.lineShownTime = currentLine.Time
+	}
+}
 
-		o.lastLyricText = currentLine.Text
+// checkDrift records the current line for drift detection. Once enough lines
+// have been crossed to judge, it logs drift beyond driftThreshold, once per
+// track. With auto offset enabled it corrects the drift instead, for the
+// rest of the track, by up to maxDriftStep at a time.
+func (o *Orchestrator) checkDrift(lineTime, position time.Duration) {
+	drift, ok := o.drift.observe(lineTime, position, o.clock.Now())
+	if !ok || (drift < driftThreshold && drift > -driftThreshold) {
+		return
+	}
 
-		// Notify status callback if set
-		if o.statusCallback != nil {
-			o.statusCallback(currentLine.Text)
+	direction := "behind"
+	if drift < 0 {
+		direction = "ahead of"
+	}
+	if !o.autoOffset {
+		// Drift usually keeps growing, so only report it once per track
+		if o.driftReported {
+			return
 		}
+		o.driftReported = true
+		log.Printf("Player position is drifting %v %s the clock, adding %d ms to lyric_offset_ms would compensate at this point in the track",
+			drift.Abs().Round(time.Millisecond), direction, drift.Milliseconds())
+		return
+	}
+
+	step := max(-maxDriftStep, min(maxDriftStep, drift))
+	corrected := max(-maxDriftCorrection, min(maxDriftCorrection, o.driftCorrection+step))
+	step = corrected - o.driftCorrection
+	if step == 0 {
+		debuglog.Printf("drift of %v is beyond the automatic correction limit", drift.Round(time.Millisecond))
+		return
+	}
+
+	o.driftCorrection = corrected
+	o.drift.shift(step)
+	log.Printf("Player position is drifting %v %s the clock, moving lyrics by %v (%v in total for this track)",
+		drift.Abs().Round(time.Millisecond), direction, step, corrected)
+}
+
+// manualLine returns the line picked with NextLine or PrevLine, or nil if
+// there is none for this song or it has expired
+func (o *Orchestrator) manualLine(song *detector.SongInfo) *lyrics.LyricLine {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if o.manualTrack != trackKey(song) || o.clock.Now().After(o.manualUntil) {
+		return nil
+	}
+	if o.manualIndex < 0 || o.manualIndex >= len(o.currentLyrics.Lines) {
+		return nil
+	}
+	return &o.currentLyrics.Lines[o.manualIndex]
+}
+
+// implausiblePosition describes what's wrong with a song's position, or
+// returns an empty string if it looks valid
+func implausiblePosition(song *detector.SongInfo) string {
+	if song.Position < 0 {
+		return fmt.Sprintf("negative position %v", song.Position)
+	}
+	if song.Duration > 0 && song.Position > song.Duration+positionSlack {
+		return fmt.Sprintf("position %v is past the track length %v", song.Position, song.Duration)
+	}
+	return ""
+}
+
+// isStream reports whether a song comes from a live stream such as internet
+// radio, where the player reports no track length
+func isStream(song *detector.SongInfo) bool {
+	return song.Duration == 0
+}
+
+// showLine writes a new current line to the clipboard and notifies listeners
+func (o *Orchestrator) showLine(line, clipboardText string, song *detector.SongInfo) {
+	if o.GetUpdateClipboard() && !o.InQuietHours() && !o.copyOnDemand {
+		if err := o.writeClipboard(o.historyText(truncateText(clipboardText, o.maxLineLength))); err != nil {
+			log.Printf("Failed to update clipboard: %v", err)
+			return
+		}
+	}
+
+	o.mu.Lock()
+	o.lastLyricText = line
+	o.lineClipboard = clipboardText
+	o.mu.Unlock()
+	o.lineShownAt = o.clock.Now()
+
+	o.setStatus(line)
+	o.emit(Event{Type: EventLineChange, Song: *song, Line: line})
+}
+
+// trackKey identifies a track for detecting song changes. The player's track
+// ID tells apart tracks with the same artist and title, but is combined with
+// them since some players report the same ID for every track. Artist and
+// title are normalized, so players that update metadata with different
+// spacing or case, as browsers do, don't trigger a new fetch.
+func trackKey(song *detector.SongInfo) string {
+	key := fmt.Sprintf("%s - %s", normalizeKey(song.Artist), normalizeKey(song.Title))
+	if song.TrackID != "" {
+		key = song.TrackID + "|" + key
+	}
+	return key
+}
+
+// normalizeKey trims and case-folds s and collapses runs of whitespace
+func normalizeKey(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// atTrackEnd reports whether playback is within the track end window of the
+// song's duration. Songs of unknown duration never end.
+func (o *Orchestrator) atTrackEnd(song *detector.SongInfo) bool {
+	return song.Duration > 0 && song.Position >= song.Duration-o.trackEndWindow
+}
+
+// loadLyrics fetches the lyrics for a song and applies romanization and
+// translations if enabled
+func (o *Orchestrator) loadLyrics(song *detector.SongInfo) (*lyrics.SyncedLyrics, error) {
+	songLyrics, err := o.lyricsFetcher.FetchLyrics(song.Artist, song.Title, song.Album, song.Duration)
+	if err != nil {
+		return nil, err
+	}
+
+	o.mu.Lock()
+	o.lastFetch = o.clock.Now()
+	o.mu.Unlock()
+
+	return o.prepareLyrics(song, songLyrics), nil
+}
+
+// prepareLyrics returns a copy of the lyrics with annotations stripped and
+// romanization and translations applied if enabled. The fetched lyrics are
+// left alone, as the fetcher's cache and the previous song's readers may
+// share them.
+func (o *Orchestrator) prepareLyrics(song *detector.SongInfo, fetched *lyrics.SyncedLyrics) *lyrics.SyncedLyrics {
+	songLyrics := fetched.Clone()
+	if o.annotations != nil {
+		songLyrics.StripAnnotations(o.annotations)
+	}
+
+	if o.romanize {
+		if err := songLyrics.Romanize(o.transliterator); err != nil {
+			log.Printf("Failed to romanize lyrics: %v", err)
+		}
+	}
+
+	// Attach a local translation if one exists
+	if o.showTranslation && o.translationDir != "" {
+		if translated, err := lyrics.LoadTranslation(o.translationDir, song.Artist, song.Title); err == nil {
+			songLyrics.AlignTranslation(translated)
+			log.Printf("Loaded translation (%d lines)", len(translated.Lines))
+		}
+	}
+	return songLyrics
+}
+
+// reloadLyrics drops the current song's cached lyrics and fetches them again.
+// The previous lyrics are kept if the fetch fails.
+func (o *Orchestrator) reloadLyrics(song *detector.SongInfo) {
+	if invalidator, ok := o.lyricsFetcher.(LyricsInvalidator); ok {
+		invalidator.Invalidate(song.Artist, song.Title, song.Album, song.Duration)
+	}
+
+	songLyrics, err := o.loadLyrics(song)
+	if err != nil {
+		log.Printf("Failed to reload lyrics for %s: %v", o.currentSongKey, err)
+		o.setStatus("Failed to reload lyrics")
+		return
+	}
+
+	o.mu.Lock()
+	o.currentLyrics = songLyrics
+	o.lastLyricText = ""
+	o.mu.Unlock()
+	o.trackEnded = false
+	o.setState(StateActive, *song)
+
+	log.Printf("Reloaded lyrics for %s", o.currentSongKey)
+	o.setStatus("Lyrics reloaded")
+}
+
+// setState records the orchestrator's state and emits an event if it changed
+func (o *Orchestrator) setState(state State, song detector.SongInfo) {
+	if state == o.state {
+		return
+	}
+	o.mu.Lock()
+	o.state = state
+	o.mu.Unlock()
+	o.emit(Event{Type: EventStateChange, Song: song, State: state})
+}
+
+// GetState returns what the orchestrator is currently doing
+func (o *Orchestrator) GetState() State {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.state
+}
+
+// setStatus passes a status message to the status callback, if set
+func (o *Orchestrator) setStatus(status string) {
+	if o.statusCallback != nil {
+		o.statusCallback(status)
+	}
+}
+
+// lineData is the data available to clipboard templates
+type lineData struct {
+	Line        string // Lyric text, romanized if enabled
+	Original    string // Lyric text as fetched, including any stripped annotations
+	Romanized   string
+	Translation string
+	Timestamp   string // Line time as mm:ss
+	Artist      string
+	Title       string
+	Album       string
+}
+
+// clipboardText returns the text to write to the clipboard for a lyric line
+func (o *Orchestrator) clipboardText(line *lyrics.LyricLine, song *detector.SongInfo) string {
+	text := line.Text
+	if o.romanize && line.Romanized != "" {
+		text = line.Romanized
+	}
+
+	var translation string
+	if o.showTranslation {
+		translation = line.Translation
+	}
+
+	if o.clipboardTmpl != nil {
+		original := line.Text
+		if line.Raw != "" {
+			original = line.Raw
+		}
+
+		data := lineData{
+			Line:        text,
+			Original:    original,
+			Romanized:   line.Romanized,
+			Translation: translation,
+			Timestamp:   formatDuration(line.Time),
+			Artist:      song.Artist,
+			Title:       song.Title,
+			Album:       song.Album,
+		}
+
+		var buf bytes.Buffer
+		if err := o.clipboardTmpl.Execute(&buf, data); err != nil {
+			log.Printf("Failed to render clipboard template: %v", err)
+			return text
+		}
+		return buf.String()
+	}
+
+	if o.includeTimestamp {
+		text = fmt.Sprintf("[%s] %s", formatDuration(line.Time), text)
+	}
+	if translation != "" {
+		return text + "\n" + translation
+	}
+	return text
+}
+
+// writeClipboard writes a lyric line to the clipboard. With a debounce window
+// configured, lines arriving within the window are coalesced and only the
+// latest is written when the window ends. With a minimum interval, writes
+// are also held back until the interval since the last write has passed,
+// and then the latest line is written.
+func (o *Orchestrator) writeClipboard(text string) error {
+	if o.clipboardDebounce <= 0 && o.clipboardMinInterval <= 0 {
+		return o.putClipboard(text)
+	}
+
+	o.clipboardMu.Lock()
+	o.pendingClipboard = text
+	if o.hasPending {
+		o.clipboardMu.Unlock()
+		return nil
+	}
+
+	delay := o.clipboardDebounce
+	if wait := o.lastClipboardWrite.Add(o.clipboardMinInterval).Sub(o.clock.Now()); wait > delay {
+		delay = wait
+	}
+	if delay > 0 {
+		o.hasPending = true
+		o.clipboardTimer = o.clock.AfterFunc(delay, o.flushClipboard)
+		o.clipboardMu.Unlock()
+		return nil
+	}
+
+	o.lastClipboardWrite = o.clock.Now()
+	o.clipboardMu.Unlock()
+	return o.putClipboard(text)
+}
+
+// flushClipboard writes the pending clipboard line, if any
+func (o *Orchestrator) flushClipboard() {
+	o.clipboardMu.Lock()
+	text, pending := o.pendingClipboard, o.hasPending
+	o.hasPending = false
+	if pending {
+		o.lastClipboardWrite = o.clock.Now()
+	}
+	o.clipboardMu.Unlock()
+
+	if !pending {
+		return
+	}
+	if err := o.putClipboard(text); err != nil {
+		log.Printf("Failed to update clipboard: %v", err)
+	}
+}
+
+// putClipboard writes text to the clipboard according to the clipboard mode
+func (o *Orchestrator) putClipboard(text string) error {
+	if o.clipboardMode != ClipboardModeAppend {
+		return o.setClipboard(text)
+	}
+
+	reader, ok := o.clipboardMgr.(ClipboardReader)
+	if !ok {
+		return o.setClipboard(text)
+	}
+
+	current, err := reader.Read()
+	if err != nil {
+		return err
+	}
+
+	// Don't repeat a line that is already at the end
+	current = strings.TrimRight(current, "\n")
+	if strings.HasSuffix(current, text) {
+		return nil
+	}
+
+	if current != "" {
+		text = current + "\n" + text
+	}
+	return o.setClipboard(trimFront(text, o.clipboardMaxLen))
+}
+
+// setClipboard writes text to the clipboard and remembers it, so
+// clearNoSong can tell whether the user has copied something since
+func (o *Orchestrator) setClipboard(text string) error {
+	if err := o.clipboardMgr.Write(text); err != nil {
+		return err
+	}
+
+	o.clipboardMu.Lock()
+	o.lastClipboardText = text
+	o.clipboardMu.Unlock()
+	return nil
+}
+
+// clearNoSong replaces the last lyric on the clipboard with the no-song
+// text. The clipboard is left alone if it can't be read back or holds
+// something other than what was last written, e.g. text the user copied.
+func (o *Orchestrator) clearNoSong() {
+	if !o.clearOnNoSong || !o.GetUpdateClipboard() || o.InQuietHours() || o.copyOnDemand {
+		return
+	}
+	o.discardPendingClipboard()
+
+	o.clipboardMu.Lock()
+	last := o.lastClipboardText
+	o.clipboardMu.Unlock()
+	if last == "" || last == o.noSongText {
+		return
+	}
+
+	reader, ok := o.clipboardMgr.(ClipboardReader)
+	if !ok {
+		return
+	}
+	current, err := reader.Read()
+	if err != nil {
+		debuglog.Printf("can't read the clipboard, leaving it alone: %v", err)
+		return
+	}
+	if strings.TrimRight(current, "\r\n") != strings.TrimRight(last, "\r\n") {
+		debuglog.Printf("clipboard changed since the last lyric, leaving it alone")
+		return
+	}
+
+	if err := o.setClipboard(o.noSongText); err != nil {
+		log.Printf("Failed to update clipboard: %v", err)
+	}
+}
+
+// trimFront drops whole lines from the start of text until it is at most
+// maxLen bytes. A single line longer than maxLen is cut mid-line.
+func trimFront(text string, maxLen int) string {
+	if maxLen <= 0 || len(text) <= maxLen {
+		return text
+	}
+
+	cut := len(text) - maxLen
+	if i := strings.IndexByte(text[cut:], '\n'); i >= 0 && cut+i+1 < len(text) {
+		return text[cut+i+1:]
+	}
+
+	// Don't split a multi-byte character
+	for cut < len(text) && !utf8.RuneStart(text[cut]) {
+		cut++
+	}
+	return text[cut:]
+}
+
+// historyText adds a line to the clipboard history and returns the latest
+// historyLines distinct lines, oldest first. A line that is already in the
+// history, e.g. from a repeated chorus, moves to the end. Empty text, used
+// to clear the clipboard, is returned as is.
+func (o *Orchestrator) historyText(text string) string {
+	if o.historyLines <= 1 || text == "" {
+		return text
+	}
+
+	o.history = append(slices.DeleteFunc(o.history, func(line string) bool { return line == text }), text)
+	if extra := len(o.history) - o.historyLines; extra > 0 {
+		o.history = o.history[extra:]
+	}
+	return strings.Join(o.history, "\n")
+}
+
+// truncateText cuts text to at most maxLen characters, replacing the end
+// with an ellipsis. Zero or less means no limit.
+func truncateText(text string, maxLen int) string {
+	if maxLen <= 0 || utf8.RuneCountInString(text) <= maxLen {
+		return text
+	}
+
+	// Keep room for the ellipsis and cut on a character boundary
+	kept := 0
+	for i := range text {
+		if kept == maxLen-1 {
+			return strings.TrimRightFunc(text[:i], unicode.IsSpace) + "…"
+		}
+		kept++
+	}
+	return text
+}
+
+// discardPendingClipboard drops a pending clipboard line without writing it
+func (o *Orchestrator) discardPendingClipboard() {
+	o.clipboardMu.Lock()
+	defer o.clipboardMu.Unlock()
+
+	if o.clipboardTimer != nil {
+		o.clipboardTimer.Stop()
+	}
+	o.hasPending = false
+}
+
+// emit delivers an event to all registered handlers
+func (o *Orchestrator) emit(event Event) {
+	for _, handler := range o.eventHandlers {
+		handler(event)
 	}
 }
 
 // Stop stops the orchestrator
 func (o *Orchestrator) Stop() {
 	close(o.stopChan)
+
+	// Write the last line rather than losing it to the debounce window
+	o.clipboardMu.Lock()
+	if o.clipboardTimer != nil {
+		o.clipboardTimer.Stop()
+	}
+	o.clipboardMu.Unlock()
+	o.flushClipboard()
+
+	if o.fifo != nil {
+		o.fifo.Close()
+	}
+
 	if o.detector != nil {
 		o.detector.Close()
 	}
@@ -160,26 +1423,436 @@ func (o *Orchestrator) SetStatusCallback(callback func(status string)) {
 	o.statusCallback = callback
 }
 
+// AddEventHandler registers a handler called for every song and line change.
+// Handlers run on the orchestrator's loop and must return quickly.
+func (o *Orchestrator) AddEventHandler(handler func(Event)) {
+	o.eventHandlers = append(o.eventHandlers, handler)
+}
+
 // GetCurrentStatus returns the current playback status
 func (o *Orchestrator) GetCurrentStatus() string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
 	if o.currentSongKey == "" {
 		return "No song detected"
 	}
+	if o.spokenWord && o.currentLyrics == nil {
+		return fmt.Sprintf("Podcast: %s", o.currentSong.Title)
+	}
+	if o.currentLyrics != nil && o.currentLyrics.Instrumental {
+		return fmt.Sprintf("Instrumental: %s", o.currentSongKey)
+	}
 	if o.lastLyricText == "" {
 		return fmt.Sprintf("Playing: %s", o.currentSongKey)
 	}
 	return fmt.Sprintf("%s: %s", o.currentSongKey, o.lastLyricText)
 }
 
+// DetectorHealthy reports whether the detector's connection is usable.
+// Detectors that don't report health are always considered healthy.
+func (o *Orchestrator) DetectorHealthy() bool {
+	if hc, ok := o.detector.(detector.HealthChecker); ok {
+		return hc.Healthy()
+	}
+	return true
+}
+
+// GetCacheStats returns the lyrics cache statistics, and false if the lyrics
+// provider doesn't keep any
+func (o *Orchestrator) GetCacheStats() (lyrics.CacheStats, bool) {
+	if reporter, ok := o.lyricsFetcher.(CacheStatsReporter); ok {
+		return reporter.Stats(), true
+	}
+	return lyrics.CacheStats{}, false
+}
+
+// GetLastFetchTime returns when lyrics were last loaded successfully, from
+// the cache or the lyrics source. It is zero if none have been loaded yet.
+func (o *Orchestrator) GetLastFetchTime() time.Time {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.lastFetch
+}
+
+// HasLyrics reports whether lyrics are loaded for the current song.
+// Instrumental tracks have no lyrics.
+func (o *Orchestrator) HasLyrics() bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.currentLyrics != nil && !o.currentLyrics.Instrumental
+}
+
+// GetLyricsContext returns the lyric lines surrounding the current one and the
+// index of the current line within them (-1 if no line is active yet)
+func (o *Orchestrator) GetLyricsContext(before, after int) ([]lyrics.LyricLine, int) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if o.currentLyrics == nil {
+		return nil, -1
+	}
+	return o.currentLyrics.GetContext(o.lastPosition, before, after)
+}
+
+// GetPosition returns the playback position last used to pick a line, with
+// the lyric offset applied
+func (o *Orchestrator) GetPosition() time.Duration {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.lastPosition
+}
+
+// GetCurrentSongKey returns the "Artist - Title" key of the current song,
+// or an empty string if no song is detected
+func (o *Orchestrator) GetCurrentSongKey() string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.currentSongKey
+}
+
+// GetCurrentSongInfo returns the most recently detected song, and false if
+// no song is playing
+func (o *Orchestrator) GetCurrentSongInfo() (*detector.SongInfo, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if o.currentSong == nil {
+		return nil, false
+	}
+	song := *o.currentSong
+	return &song, true
+}
+
+// GetCoverArt returns the current song's cover art and the URL it was
+// loaded from, which identifies the image. Fails if the player reports no
+// cover art or it can't be loaded.
+func (o *Orchestrator) GetCoverArt() ([]byte, string, error) {
+	o.mu.RLock()
+	song := o.currentSong
+	o.mu.RUnlock()
+
+	if song == nil {
+		return nil, "", fmt.Errorf("no song playing")
+	}
+	if song.ArtURL == "" {
+		return nil, "", fmt.Errorf("no cover art for %s - %s", song.Artist, song.Title)
+	}
+
+	data, err := o.art.Load(song.ArtURL)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, song.ArtURL, nil
+}
+
+// GetCurrentLine returns the lyric line most recently written, or an empty
+// string if no line is active
+func (o *Orchestrator) GetCurrentLine() string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.lastLyricText
+}
+
+// GetLyricOffset returns the current lyric offset
+func (o *Orchestrator) GetLyricOffset() time.Duration {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.lyricOffset
+}
+
+// GetLeadTime returns how early the next line may be shown
+func (o *Orchestrator) GetLeadTime() time.Duration {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.leadTime
+}
+
+// GetUpdateClipboard reports whether clipboard updates are enabled
+func (o *Orchestrator) GetUpdateClipboard() bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.updateClipboard
+}
+
+// InQuietHours reports whether clipboard updates are held back because it's
+// within the configured quiet hours
+func (o *Orchestrator) InQuietHours() bool {
+	return o.quietHours != nil && o.quietHours.contains(o.clock.Now())
+}
+
+// Pause stops detection and clipboard updates until Resume is called
+func (o *Orchestrator) Pause() {
+	o.mu.Lock()
+	o.paused = true
+	o.mu.Unlock()
+	log.Println("Paused")
+}
+
+// Resume continues detection and clipboard updates after Pause
+func (o *Orchestrator) Resume() {
+	o.mu.Lock()
+	o.paused = false
+	o.mu.Unlock()
+	log.Println("Resumed")
+}
+
+// TogglePause pauses the orchestrator if it is running and resumes it if it
+// is paused. Returns whether it is now paused.
+func (o *Orchestrator) TogglePause() bool {
+	o.mu.Lock()
+	o.paused = !o.paused
+	paused := o.paused
+	o.mu.Unlock()
+
+	if paused {
+		log.Println("Paused")
+	} else {
+		log.Println("Resumed")
+	}
+	return paused
+}
+
+// IsPaused reports whether the orchestrator is paused
+func (o *Orchestrator) IsPaused() bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.paused
+}
+
+// ReloadLyrics asks the loop to fetch the current song's lyrics again,
+// bypassing the cache
+func (o *Orchestrator) ReloadLyrics() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.currentSongKey == "" {
+		return fmt.Errorf("no song playing")
+	}
+	o.reloadRequested = true
+	return nil
+}
+
+// SearchLyricsCandidates looks up the lyrics versions available for the
+// current song. The results are kept for ChooseLyricsCandidate.
+func (o *Orchestrator) SearchLyricsCandidates() ([]lyrics.Candidate, error) {
+	searcher, ok := o.lyricsFetcher.(LyricsSearcher)
+	if !ok {
+		return nil, fmt.Errorf("lyrics provider does not support searching")
+	}
+
+	o.mu.RLock()
+	song := o.currentSong
+	o.mu.RUnlock()
+	if song == nil {
+		return nil, fmt.Errorf("no song playing")
+	}
+
+	candidates, err := searcher.Search(song.Artist, song.Title, song.Duration, MaxLyricsCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	o.mu.Lock()
+	o.candidates = candidates
+	o.candidatesTrack = trackKey(song)
+	o.mu.Unlock()
+
+	return candidates, nil
+}
+
+// GetLyricsCandidates returns the lyrics versions last found for the current
+// song, or nil if it hasn't been searched
+func (o *Orchestrator) GetLyricsCandidates() []lyrics.Candidate {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if o.currentSong == nil || o.candidatesTrack != trackKey(o.currentSong) {
+		return nil
+	}
+	return o.candidates
+}
+
+// ChooseLyricsCandidate switches the current song to the lyrics version at
+// index in the last search results. The choice is cached, so it is kept when
+// the song plays again.
+func (o *Orchestrator) ChooseLyricsCandidate(index int) error {
+	searcher, ok := o.lyricsFetcher.(LyricsSearcher)
+	if !ok {
+		return fmt.Errorf("lyrics provider does not support searching")
+	}
+
+	o.mu.RLock()
+	song, candidates, track := o.currentSong, o.candidates, o.candidatesTrack
+	o.mu.RUnlock()
+	if song == nil || track != trackKey(song) {
+		return fmt.Errorf("no lyrics versions found for the current song")
+	}
+	if index < 0 || index >= len(candidates) {
+		return fmt.Errorf("no lyrics version %d", index)
+	}
+
+	songLyrics, err := searcher.UseCandidate(song.Artist, song.Title, song.Album, song.Duration, candidates[index])
+	if err != nil {
+		return err
+	}
+	songLyrics = o.prepareLyrics(song, songLyrics)
+
+	o.mu.Lock()
+	o.chosenLyrics = songLyrics
+	o.chosenTrack = track
+	o.mu.Unlock()
+	return nil
+}
+
+// NextLine moves to the line after the current one, for when the timing is
+// off. The loop shows it on its next tick and keeps it for manualLineHold,
+// or until the song changes, before following the playback position again.
+func (o *Orchestrator) NextLine() error {
+	return o.stepLine(1)
+}
+
+// PrevLine moves to the line before the current one, see NextLine
+func (o *Orchestrator) PrevLine() error {
+	return o.stepLine(-1)
+}
+
+// stepLine overrides the current line with the one delta lines away
+func (o *Orchestrator) stepLine(delta int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.currentSong == nil || o.currentLyrics == nil || len(o.currentLyrics.Lines) == 0 {
+		return fmt.Errorf("no synced lyrics loaded")
+	}
+
+	// Step from the line picked last, or else the timed one
+	track := trackKey(o.currentSong)
+	index := o.manualIndex
+	if o.manualTrack != track || o.clock.Now().After(o.manualUntil) {
+		index = -1
+		for i, line := range o.currentLyrics.Lines {
+			if line.Time > o.lastPosition {
+				break
+			}
+			index = i
+		}
+	}
+
+	index += delta
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(o.currentLyrics.Lines) {
+		index = len(o.currentLyrics.Lines) - 1
+	}
+
+	o.manualIndex = index
+	o.manualTrack = track
+	o.manualUntil = o.clock.Now().Add(manualLineHold)
+	return nil
+}
+
+// CopyAllLyrics writes the full lyrics of the current song to the clipboard
+func (o *Orchestrator) CopyAllLyrics() error {
+	o.mu.RLock()
+	songKey, songLyrics := o.currentSongKey, o.currentLyrics
+	o.mu.RUnlock()
+
+	if songLyrics == nil {
+		return fmt.Errorf("no lyrics loaded")
+	}
+
+	text := songLyrics.FullText()
+	if text == "" {
+		return fmt.Errorf("lyrics are empty")
+	}
+
+	// Don't let a pending line overwrite the full lyrics
+	o.discardPendingClipboard()
+
+	if err := o.clipboardMgr.Write(text); err != nil {
+		return err
+	}
+	log.Printf("Copied full lyrics for %s to clipboard", songKey)
+	return nil
+}
+
+// CopyCurrentLine writes the current line to the clipboard as it would be
+// copied automatically, for copy on demand mode. It works in the other
+// modes too, e.g. to copy a line again after copying something else.
+func (o *Orchestrator) CopyCurrentLine() error {
+	o.mu.RLock()
+	text := o.lineClipboard
+	o.mu.RUnlock()
+
+	if text == "" {
+		return fmt.Errorf("no current line")
+	}
+
+	// Don't let a pending line overwrite the requested one
+	o.discardPendingClipboard()
+
+	if err := o.setClipboard(truncateText(text, o.maxLineLength)); err != nil {
+		return err
+	}
+	log.Printf("Copied current line to clipboard")
+	return nil
+}
+
+// ExportCurrentLyrics writes the current song's lyrics to path in LRC
+// format, e.g. to keep them or to correct the timing by hand
+func (o *Orchestrator) ExportCurrentLyrics(path string) error {
+	o.mu.RLock()
+	songKey, songLyrics := o.currentSongKey, o.currentLyrics
+	o.mu.RUnlock()
+
+	if songLyrics == nil {
+		return fmt.Errorf("no lyrics loaded")
+	}
+	if len(songLyrics.Lines) == 0 {
+		return fmt.Errorf("lyrics are not synced")
+	}
+
+	if err := os.WriteFile(path, []byte(songLyrics.FormatLRC()), 0644); err != nil {
+		return fmt.Errorf("failed to export lyrics: %w", err)
+	}
+	log.Printf("Exported lyrics for %s to %s", songKey, path)
+	return nil
+}
+
 // SetLyricOffset updates the lyric offset dynamically
 func (o *Orchestrator) SetLyricOffset(offset time.Duration) {
+	o.mu.Lock()
 	o.lyricOffset = offset
+	o.mu.Unlock()
+	log.Printf("Lyric offset updated to %v", offset)
+}
+
+// AdjustLyricOffset shifts the lyric offset by delta and returns the new offset
+func (o *Orchestrator) AdjustLyricOffset(delta time.Duration) time.Duration {
+	o.mu.Lock()
+	o.lyricOffset += delta
+	offset := o.lyricOffset
+	o.mu.Unlock()
+
 	log.Printf("Lyric offset updated to %v", offset)
+	return offset
+}
+
+// SetLeadTime updates how early the next line may be shown
+func (o *Orchestrator) SetLeadTime(leadTime time.Duration) {
+	o.mu.Lock()
+	o.leadTime = leadTime
+	o.mu.Unlock()
+	log.Printf("Lead time updated to %v", leadTime)
 }
 
 // SetUpdateClipboard enables or disables clipboard updates
 func (o *Orchestrator) SetUpdateClipboard(enabled bool) {
+	o.mu.Lock()
 	o.updateClipboard = enabled
+	o.mu.Unlock()
 	if enabled {
 		log.Println("Clipboard updates enabled")
 	} else {
@@ -187,6 +1860,16 @@ func (o *Orchestrator) SetUpdateClipboard(enabled bool) {
 	}
 }
 
+// SetShowNotifications enables or disables song change notifications
+func (o *Orchestrator) SetShowNotifications(enabled bool) {
+	o.notifier.SetEnabled(enabled)
+	if enabled {
+		log.Println("Notifications enabled")
+	} else {
+		log.Println("Notifications disabled")
+	}
+}
+
 // formatDuration formats a duration as mm:ss
 func formatDuration(d time.Duration) string {
 	minutes := int(d.Minutes())
@@ -0,0 +1,41 @@
+package orchestrator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/detector"
+)
+
+// podcastPlayers are podcast and audiobook apps, matched against the
+// player's D-Bus name or application id like ignored players. Everything
+// they play is spoken word, so there are no lyrics to look up.
+var podcastPlayers = []string{
+	"podcast", // GNOME Podcasts, Pocket Casts, Apple Podcasts
+	"gpodder",
+	"kasts",
+	"vocal",
+	"cozy",
+	"audiobook", // Audiobookshelf, Audiobooks
+	"bookworm",
+}
+
+// isSpokenWord reports whether the song looks like a podcast episode or
+// audiobook chapter rather than music, and why. Tracks longer than
+// longTrack count as spoken word unless longTrack is zero.
+func isSpokenWord(song *detector.SongInfo, longTrack time.Duration) (bool, string) {
+	if detector.IsIgnoredPlayer(song.Source, podcastPlayers) {
+		return true, "played by a podcast player"
+	}
+	// Streams have no duration and often no artist; radio mode handles them
+	if isStream(song) {
+		return false, ""
+	}
+	if song.Artist == "" {
+		return true, "no artist"
+	}
+	if longTrack > 0 && song.Duration >= longTrack {
+		return true, fmt.Sprintf("longer than %d minutes", int(longTrack.Minutes()))
+	}
+	return false, ""
+}
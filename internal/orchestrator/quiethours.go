@@ -0,0 +1,53 @@
+package orchestrator
+
+import (
+	"fmt"
+	"time"
+)
+
+// quietHours is a daily window during which the clipboard is left alone.
+// Times are offsets from midnight; a window whose end is before its start
+// crosses midnight.
+type quietHours struct {
+	start time.Duration
+	end   time.Duration
+}
+
+// parseQuietHours parses a window given as "HH:MM" times. Returns nil if
+// both are empty, meaning there are no quiet hours.
+func parseQuietHours(start, end string) (*quietHours, error) {
+	if start == "" && end == "" {
+		return nil, nil
+	}
+	if start == "" || end == "" {
+		return nil, fmt.Errorf("quiet hours need both a start and an end")
+	}
+
+	var q quietHours
+	var err error
+	if q.start, err = parseTimeOfDay(start); err != nil {
+		return nil, err
+	}
+	if q.end, err = parseTimeOfDay(end); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// parseTimeOfDay parses "HH:MM" into an offset from midnight
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// contains reports whether t falls within the quiet hours
+func (q *quietHours) contains(t time.Time) bool {
+	now := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if q.start <= q.end {
+		return now >= q.start && now < q.end
+	}
+	return now >= q.start || now < q.end
+}
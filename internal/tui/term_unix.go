@@ -0,0 +1,46 @@
+//go:build !windows
+
+package tui
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// ANSI sequences to hide and show the cursor
+const (
+	hideCursor = "\x1b[?25l"
+	showCursor = "\x1b[?25h"
+)
+
+// terminalWidth returns the width of the terminal f is attached to, or 0
+// if f isn't a terminal
+func terminalWidth(f *os.File) int {
+	var size struct {
+		rows, cols, xpixel, ypixel uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size)))
+	if errno != 0 {
+		return 0
+	}
+	return int(size.cols)
+}
+
+// watchResize calls redraw whenever the terminal is resized, until stop is
+// closed
+func watchResize(stop <-chan struct{}, redraw func()) {
+	resized := make(chan os.Signal, 1)
+	signal.Notify(resized, syscall.SIGWINCH)
+	defer signal.Stop(resized)
+
+	for {
+		select {
+		case <-resized:
+			redraw()
+		case <-stop:
+			return
+		}
+	}
+}
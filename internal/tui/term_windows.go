@@ -0,0 +1,63 @@
+package tui
+
+import (
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// The console may not interpret ANSI sequences, so the cursor is left as is
+const (
+	hideCursor = ""
+	showCursor = ""
+)
+
+// resizeCheckInterval is how often the console's width is checked, as
+// Windows doesn't signal resizes
+const resizeCheckInterval = time.Second
+
+var (
+	kernel32                       = syscall.NewLazyDLL("kernel32.dll")
+	procGetConsoleScreenBufferInfo = kernel32.NewProc("GetConsoleScreenBufferInfo")
+)
+
+// consoleScreenBufferInfo is CONSOLE_SCREEN_BUFFER_INFO
+type consoleScreenBufferInfo struct {
+	size              [2]int16
+	cursorPosition    [2]int16
+	attributes        uint16
+	window            [4]int16 // Left, top, right, bottom
+	maximumWindowSize [2]int16
+}
+
+// terminalWidth returns the width of the console window f is attached to,
+// or 0 if f isn't a console
+func terminalWidth(f *os.File) int {
+	var info consoleScreenBufferInfo
+	ret, _, _ := procGetConsoleScreenBufferInfo.Call(f.Fd(), uintptr(unsafe.Pointer(&info)))
+	if ret == 0 {
+		return 0
+	}
+	return int(info.window[2]-info.window[0]) + 1
+}
+
+// watchResize calls redraw when the console's width changes, until stop is
+// closed
+func watchResize(stop <-chan struct{}, redraw func()) {
+	ticker := time.NewTicker(resizeCheckInterval)
+	defer ticker.Stop()
+
+	width := terminalWidth(os.Stdout)
+	for {
+		select {
+		case <-ticker.C:
+			if w := terminalWidth(os.Stdout); w != width {
+				width = w
+				redraw()
+			}
+		case <-stop:
+			return
+		}
+	}
+}
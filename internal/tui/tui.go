@@ -0,0 +1,158 @@
+// Package tui shows the current song and lyric line on a single terminal
+// line that is redrawn in place.
+package tui
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/arnavpraneet/lyric-clipboard-app/internal/orchestrator"
+	"github.com/mattn/go-runewidth"
+)
+
+// defaultWidth is used when the terminal's width can't be determined
+const defaultWidth = 80
+
+// Display keeps the song and lyric line on the terminal's last line. Log
+// messages are printed above it, so they don't break the line up.
+type Display struct {
+	out     *os.File
+	logOut  io.Writer // Where log messages went before Start
+	song    string
+	line    string
+	state   orchestrator.State
+	drawn   int // Width of the text last drawn, to clear leftovers
+	mu      sync.Mutex
+	stopped chan struct{}
+}
+
+// New creates a display that follows the orchestrator's song and line
+// changes. Nothing is drawn until Start is called.
+func New(orch *orchestrator.Orchestrator) *Display {
+	d := &Display{
+		out:     os.Stdout,
+		state:   orchestrator.StateIdle,
+		stopped: make(chan struct{}),
+	}
+	orch.AddEventHandler(d.handleEvent)
+	return d
+}
+
+// Start hides the cursor, draws the status line and routes log messages
+// above it
+func (d *Display) Start() {
+	d.mu.Lock()
+	d.logOut = log.Writer()
+	fmt.Fprint(d.out, hideCursor)
+	d.draw()
+	d.mu.Unlock()
+
+	log.SetOutput(d)
+	go watchResize(d.stopped, d.redraw)
+}
+
+// Close clears the status line, restores the cursor and sends log messages
+// back to where they went before Start
+func (d *Display) Close() {
+	close(d.stopped)
+	log.SetOutput(d.logOut)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.clear()
+	fmt.Fprint(d.out, showCursor)
+}
+
+// Write prints a log message above the status line
+func (d *Display) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.clear()
+	n, err := d.logOut.Write(p)
+	d.draw()
+	return n, err
+}
+
+// handleEvent records song, line and state changes and redraws
+func (d *Display) handleEvent(event orchestrator.Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch event.Type {
+	case orchestrator.EventSongChange:
+		d.song = fmt.Sprintf("%s - %s", event.Song.Artist, event.Song.Title)
+		d.line = ""
+	case orchestrator.EventLineChange:
+		d.line = event.Line
+	case orchestrator.EventStateChange:
+		d.state = event.State
+		if event.State == orchestrator.StateIdle {
+			d.song, d.line = "", ""
+		}
+	}
+	d.draw()
+}
+
+// redraw draws the status line again, e.g. after the terminal was resized
+func (d *Display) redraw() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.draw()
+}
+
+// text returns the status line's content
+func (d *Display) text() string {
+	if d.song == "" {
+		return "♪ Waiting for a song..."
+	}
+
+	line := d.line
+	if line == "" {
+		switch d.state {
+		case orchestrator.StateFetching:
+			line = "Fetching lyrics..."
+		case orchestrator.StateNoLyrics:
+			line = "No lyrics"
+		case orchestrator.StateError:
+			line = "Failed to fetch lyrics"
+		default:
+			line = "..."
+		}
+	}
+	return fmt.Sprintf("♪ %s | %s", d.song, line)
+}
+
+// draw writes the status line over the previous one, cut to the terminal's
+// width so it never wraps. Must be called with mu held.
+func (d *Display) draw() {
+	width := terminalWidth(d.out)
+	if width <= 0 {
+		width = defaultWidth
+	}
+
+	// Leave the last column free, as some terminals wrap when it is written
+	text := runewidth.Truncate(d.text(), width-1, "…")
+	textWidth := runewidth.StringWidth(text)
+
+	padding := 0
+	if d.drawn > textWidth {
+		padding = min(d.drawn, width-1) - textWidth
+	}
+	fmt.Fprint(d.out, "\r"+text+strings.Repeat(" ", padding))
+	d.drawn = textWidth
+}
+
+// clear blanks the status line and returns the cursor to its start. Must be
+// called with mu held.
+func (d *Display) clear() {
+	if d.drawn == 0 {
+		return
+	}
+	fmt.Fprint(d.out, "\r"+strings.Repeat(" ", d.drawn)+"\r")
+	d.drawn = 0
+}
@@ -0,0 +1,7 @@
+// Package version holds the application's version, set at build time with
+//
+//	go build -ldflags "-X github.com/arnavpraneet/lyric-clipboard-app/internal/version.Version=v1.2.3"
+package version
+
+// Version is the application version, "dev" for untagged builds
+var Version = "dev"